@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// modeAgent 是ask --agent的检索模式
+const modeAgent = "agent"
+
+// 代理检索中每一步的来源
+const (
+	agentOriginal    = "original"     // 原问题
+	agentSubQuestion = "sub_question" // 模型拆分出的子问题
+	agentTool        = "tool"         // 模型通过工具调用追加的检索
+)
+
+// agentSearchTool 提供给模型的检索工具名
+const agentSearchTool = "search_docs"
+
+// agentExcerptRunes 提供给模型的每个片段摘录的最大字符数
+const agentExcerptRunes = 200
+
+// decomposePrompt 将问题拆分为子问题的prompt
+const decomposePrompt = `请将下面的问题拆分为不超过 %d 个可以分别在技术文档中检索的子问题。
+比较类问题要为每个比较对象分别生成子问题，多步问题按推理步骤拆分；问题已经足够简单时只输出原问题。
+每行输出一个子问题，不要编号，不要输出其他内容，使用与原问题相同的语言。
+
+问题: %s`
+
+// followUpPrompt 让模型决定是否追加检索的prompt
+const followUpPrompt = `你正在为回答下面的问题收集技术文档。已经进行的检索及找到的片段摘录如下：
+%s
+如果还缺少回答问题所需的信息，请调用 ` + agentSearchTool + ` 工具检索缺少的内容，每次一个查询，
+检索结果会作为工具消息返回；信息已经足够时直接回复 "足够"，不要调用工具。
+
+问题: %s`
+
+// agentPlanner 代理模式中规划检索的模型
+type agentPlanner interface {
+	// Decompose 将问题拆分为最多max个子问题
+	Decompose(ctx context.Context, question string, max int) ([]string, error)
+	// FollowUp 将到目前为止的对话（首条为包含已有检索结果的用户消息，之后依次为模型的
+	// 工具调用和检索结果）发送给模型，返回模型的回复；回复中没有工具调用表示信息已经足够
+	FollowUp(ctx context.Context, messages []OllamaToolMessage) (OllamaToolMessage, error)
+}
+
+// agentStep 代理检索中的一次检索
+type agentStep struct {
+	Kind    string        `json:"kind"`
+	Query   string        `json:"query"`
+	Hits    int           `json:"hits"` // 该次检索返回的片段数
+	New     int           `json:"new"`  // 其中此前没有检索到的片段数
+	Elapsed time.Duration `json:"-"`
+}
+
+// agentTrace 代理检索的过程
+type agentTrace struct {
+	SubQuestions []string    `json:"sub_questions"`
+	Steps        []agentStep `json:"steps"`
+}
+
+// runAgent 分别检索原问题和模型拆分出的子问题，再由模型通过工具调用追加最多
+// cfg.MaxSteps 次检索，返回所有检索结果的并集。模型调用失败时只记录警告，
+// 已有的结果仍然可用；只有原问题检索失败时返回错误。
+func runAgent(ctx context.Context, question string, r Retriever, filter SearchFilter, opts searchOptions, planner agentPlanner, cfg AgentConfig) (*retrievalResult, *agentTrace, error) {
+	trace := &agentTrace{SubQuestions: []string{}, Steps: []agentStep{}}
+	merged := &retrievalResult{Mode: modeAgent}
+	seen := make(map[int]*SearchHit)
+	searched := make(map[string]bool)
+
+	// search 检索一次并合并结果，返回其中此前没有检索到的片段
+	search := func(kind, query string) ([]*SearchHit, error) {
+		searched[strings.ToLower(strings.TrimSpace(query))] = true
+		start := time.Now()
+		result, err := r.Search(ctx, query, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		var fresh []*SearchHit
+		step := agentStep{Kind: kind, Query: query, Hits: len(result.Hits), Elapsed: time.Since(start)}
+		for _, h := range result.Hits {
+			// 同一chunk被多次检索到时保留最好的得分和距离
+			if prev, ok := seen[h.Chunk.ID]; ok {
+				prev.Score = max(prev.Score, h.Score)
+				prev.Distance = min(prev.Distance, h.Distance)
+				if h.RerankScore != nil && (prev.RerankScore == nil || *h.RerankScore > *prev.RerankScore) {
+					prev.RerankScore = h.RerankScore
+				}
+				continue
+			}
+			seen[h.Chunk.ID] = h
+			merged.Hits = append(merged.Hits, h)
+			fresh = append(fresh, h)
+			step.New++
+		}
+		if kind == agentOriginal {
+			merged.QueryType = result.QueryType
+			merged.Variants = result.Variants
+			merged.Reranker = result.Reranker
+			merged.EmbedCache = result.EmbedCache
+		}
+		merged.Candidates += result.Candidates
+		merged.EmbedTime += result.EmbedTime
+		merged.ExpandTime += result.ExpandTime
+		merged.SearchTime += result.SearchTime
+		merged.RerankTime += result.RerankTime
+		trace.Steps = append(trace.Steps, step)
+		return fresh, nil
+	}
+
+	if _, err := search(agentOriginal, question); err != nil {
+		return nil, nil, err
+	}
+
+	// 1. 拆分子问题并逐个检索
+	subQuestions, err := planner.Decompose(ctx, question, cfg.SubQuestions)
+	if err != nil {
+		log.Printf("Warning: decomposing the question failed, searching the question only: %v", err)
+	}
+	for _, q := range subQuestions {
+		if searched[strings.ToLower(q)] {
+			continue
+		}
+		trace.SubQuestions = append(trace.SubQuestions, q)
+		if _, err := search(agentSubQuestion, q); err != nil {
+			log.Printf("Warning: searching sub-question %q failed: %v", q, err)
+		}
+	}
+
+	// 2. 由模型决定是否追加检索：对话历史在各步之间保留，每次检索的结果作为工具消息
+	// 回复给模型；重复的查询视为信息已经足够
+	messages := []OllamaToolMessage{{Role: "user", Content: fmt.Sprintf(followUpPrompt, describeEvidence(trace, merged.Hits), question)}}
+	for step := 0; step < cfg.MaxSteps; step++ {
+		reply, err := planner.FollowUp(ctx, messages)
+		if err != nil {
+			log.Printf("Warning: asking for follow-up searches failed: %v", err)
+			break
+		}
+		call, ok := searchCall(reply)
+		if !ok {
+			break
+		}
+		query := strings.TrimSpace(call.Function.Arguments["query"].(string))
+		if query == "" || searched[strings.ToLower(query)] {
+			break
+		}
+		// 每次只执行一个工具调用，历史中只保留这一个，使每个调用都有对应的结果
+		reply.ToolCalls = []OllamaToolCall{call}
+		fresh, err := search(agentTool, query)
+		if err != nil {
+			log.Printf("Warning: searching %q failed: %v", query, err)
+			break
+		}
+		last := trace.Steps[len(trace.Steps)-1]
+		messages = append(messages, reply, OllamaToolMessage{
+			Role:     "tool",
+			ToolName: agentSearchTool,
+			Content:  describeToolResult(last, fresh),
+		})
+	}
+
+	merged.Details = fmt.Sprintf("代理检索: %d 次检索合并为 %d 个片段", len(trace.Steps), len(merged.Hits))
+	return merged, trace, nil
+}
+
+// describeEvidence 为模型列出已经进行的检索及找到的片段摘录
+func describeEvidence(trace *agentTrace, hits []*SearchHit) string {
+	var b strings.Builder
+	for _, s := range trace.Steps {
+		fmt.Fprintf(&b, "- 检索 %q: 找到 %d 个片段\n", s.Query, s.Hits)
+	}
+	b.WriteString("找到的片段:\n")
+	writeExcerpts(&b, hits)
+	return b.String()
+}
+
+// describeToolResult 描述一次追加检索的结果，作为工具消息回复给模型
+func describeToolResult(step agentStep, fresh []*SearchHit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "检索 %q 找到 %d 个片段，其中 %d 个是新的", step.Query, step.Hits, step.New)
+	if len(fresh) == 0 {
+		b.WriteString("。\n")
+		return b.String()
+	}
+	b.WriteString(":\n")
+	writeExcerpts(&b, fresh)
+	return b.String()
+}
+
+// writeExcerpts 每个片段输出来源和开头的摘录
+func writeExcerpts(b *strings.Builder, hits []*SearchHit) {
+	for _, h := range hits {
+		label := h.Chunk.Path
+		if h.Chunk.Heading != "" {
+			label += " § " + h.Chunk.Heading
+		}
+		fmt.Fprintf(b, "- %s: %s\n", label, excerpt(h.Chunk.Data, agentExcerptRunes))
+	}
+}
+
+// excerpt 将文本中的空白合并为一个空格，并截取前n个字符
+func excerpt(text string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n]) + "…"
+}
+
+// searchCall 返回回复中第一个带查询参数的检索工具调用
+func searchCall(reply OllamaToolMessage) (OllamaToolCall, bool) {
+	for _, call := range reply.ToolCalls {
+		if call.Function.Name != agentSearchTool {
+			continue
+		}
+		if _, ok := call.Function.Arguments["query"].(string); ok {
+			return call, true
+		}
+	}
+	return OllamaToolCall{}, false
+}
+
+// parseSubQuestions 解析模型输出的子问题，每行一个，去除编号和重复
+func parseSubQuestions(reply string, max int) []string {
+	var questions []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(listMarker.ReplaceAllString(line, ""))
+		key := strings.ToLower(line)
+		if line == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		questions = append(questions, line)
+		if len(questions) == max {
+			break
+		}
+	}
+	return questions
+}
+
+// printAgentTrace 输出代理检索的每一步
+func printAgentTrace(w io.Writer, trace *agentTrace) {
+	fmt.Fprintf(w, "   🧭 代理检索: %d 个子问题, %d 次检索\n", len(trace.SubQuestions), len(trace.Steps))
+	for i, s := range trace.Steps {
+		fmt.Fprintf(w, "      [%d] %s (⏱️ %v, %d 个片段, %d 个新片段): %s\n", i+1, s.Kind, s.Elapsed, s.Hits, s.New, s.Query)
+	}
+}
+
+// ollamaPlanner 规划检索：问题拆分通过Generator完成，追加检索通过Ollama
+// /api/chat 的工具调用实现
+type ollamaPlanner struct {
+	url       string
+	model     string
+	numCtx    int
+	generator Generator // 拆分问题使用的模型
+}
+
+// newOllamaPlanner 按配置创建ollamaPlanner，g与agent.model相同时直接用于拆分问题
+func newOllamaPlanner(cfg *Config, g Generator) (*ollamaPlanner, error) {
+	generator, err := generatorFor(cfg, g, cfg.Agent.Model)
+	if err != nil {
+		return nil, err
+	}
+	return &ollamaPlanner{
+		url:       cfg.Ollama.URL,
+		model:     cfg.Agent.Model,
+		numCtx:    cfg.Ollama.ChatModelConfig(cfg.Agent.Model).NumCtx,
+		generator: generator,
+	}, nil
+}
+
+// Decompose 由模型拆分问题，结果缓存在问答缓存中
+func (p *ollamaPlanner) Decompose(ctx context.Context, question string, max int) ([]string, error) {
+	reply, err := cachedGenerate(ctx, p.generator, fmt.Sprintf(decomposePrompt, max, question), GenerationOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return parseSubQuestions(reply, max), nil
+}
+
+// OllamaTool 是Ollama /api/chat 请求中的一个工具定义
+type OllamaTool struct {
+	Type     string             `json:"type"`
+	Function OllamaToolFunction `json:"function"`
+}
+
+// OllamaToolFunction 描述工具的名称和参数（JSON Schema）
+type OllamaToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// OllamaToolCall 是模型回复中的一次工具调用
+type OllamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+// OllamaToolMessage 是带工具的 /api/chat 对话中的一条消息：assistant消息可以带工具调用，
+// tool消息为工具调用的结果
+type OllamaToolMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []OllamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+// OllamaToolRequest 是带工具的 /api/chat 请求
+type OllamaToolRequest struct {
+	Model    string              `json:"model"`
+	Messages []OllamaToolMessage `json:"messages"`
+	Tools    []OllamaTool        `json:"tools"`
+	Stream   bool                `json:"stream"`
+	Options  map[string]any      `json:"options,omitempty"`
+}
+
+// OllamaToolResponse 是带工具的 /api/chat 的非流式响应
+type OllamaToolResponse struct {
+	Message OllamaToolMessage `json:"message"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// searchDocsTool 提供给模型的文档检索工具
+var searchDocsTool = OllamaTool{
+	Type: "function",
+	Function: OllamaToolFunction{
+		Name:        agentSearchTool,
+		Description: "在技术文档中检索与查询相关的片段",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string", "description": "检索查询"},
+			},
+			"required": []string{"query"},
+		},
+	},
+}
+
+// FollowUp 提供检索工具，将对话发送给模型并返回模型的回复
+func (p *ollamaPlanner) FollowUp(ctx context.Context, messages []OllamaToolMessage) (OllamaToolMessage, error) {
+	resp, err := postJSON(ctx, p.url+"/api/chat", OllamaToolRequest{
+		Model:    p.model,
+		Messages: messages,
+		Tools:    []OllamaTool{searchDocsTool},
+		Options:  map[string]any{"num_ctx": p.numCtx, "temperature": 0},
+	}, nil)
+	if err != nil {
+		return OllamaToolMessage{}, err
+	}
+	defer resp.Body.Close()
+	var toolResp OllamaToolResponse
+	if err := json.NewDecoder(resp.Body).Decode(&toolResp); err != nil {
+		return OllamaToolMessage{}, fmt.Errorf("error decoding response: %v", err)
+	}
+	if toolResp.Error != "" {
+		return OllamaToolMessage{}, fmt.Errorf("API error: %s", toolResp.Error)
+	}
+	if toolResp.Message.Role == "" {
+		toolResp.Message.Role = "assistant"
+	}
+	return toolResp.Message, nil
+}
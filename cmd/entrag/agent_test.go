@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rotemtam/entrag/ent"
+)
+
+// queryRetriever 按查询返回预设片段的Retriever，并记录收到的查询
+type queryRetriever struct {
+	staticRetriever
+	hits    map[string][]*SearchHit
+	queries []string
+}
+
+// Search 返回查询对应的片段
+func (r *queryRetriever) Search(ctx context.Context, question string, filter SearchFilter, opts searchOptions) (*retrievalResult, error) {
+	r.queries = append(r.queries, question)
+	return &retrievalResult{
+		Hits:      r.hits[question],
+		QueryType: QueryTypeConfig{Name: "比较性"},
+		Mode:      modeSingle,
+	}, nil
+}
+
+// scriptedPlanner 返回预设子问题和追加检索的agentPlanner
+type scriptedPlanner struct {
+	subQuestions []string
+	decomposeErr error
+	followUps    []string
+	histories    [][]OllamaToolMessage // 每次FollowUp收到的对话
+}
+
+// Decompose 返回预设的子问题
+func (p *scriptedPlanner) Decompose(ctx context.Context, question string, max int) ([]string, error) {
+	return p.subQuestions, p.decomposeErr
+}
+
+// FollowUp 依次以工具调用返回预设的追加检索，用完后表示信息已经足够
+func (p *scriptedPlanner) FollowUp(ctx context.Context, messages []OllamaToolMessage) (OllamaToolMessage, error) {
+	p.histories = append(p.histories, append([]OllamaToolMessage(nil), messages...))
+	if len(p.followUps) == 0 {
+		return OllamaToolMessage{Role: "assistant", Content: "足够"}, nil
+	}
+	var call OllamaToolCall
+	call.Function.Name = agentSearchTool
+	call.Function.Arguments = map[string]any{"query": p.followUps[0]}
+	p.followUps = p.followUps[1:]
+	return OllamaToolMessage{Role: "assistant", ToolCalls: []OllamaToolCall{call}}, nil
+}
+
+// agentHit 返回指定ID的命中片段
+func agentHit(id int, path string, score float64) *SearchHit {
+	return &SearchHit{Chunk: &ent.Chunk{ID: id, Path: path, Data: fmt.Sprintf("chunk %d of %s", id, path)}, Score: score, Distance: 0.5}
+}
+
+func TestRunAgent(t *testing.T) {
+	r := &queryRetriever{hits: map[string][]*SearchHit{
+		"PDM和PLM系统的区别": {agentHit(1, "pdm.md", 0.03)},
+		"什么是PDM系统":     {agentHit(1, "pdm.md", 0.05), agentHit(2, "pdm.md", 0.02)},
+		"什么是PLM系统":     {agentHit(3, "plm.md", 0.04)},
+		"PLM 生命周期阶段":   {agentHit(4, "plm.md", 0.01)},
+	}}
+	planner := &scriptedPlanner{
+		subQuestions: []string{"什么是PDM系统", "什么是PLM系统", "PDM和PLM系统的区别"},
+		followUps:    []string{"PLM 生命周期阶段", "PLM 生命周期阶段", "never searched"},
+	}
+	result, trace, err := runAgent(context.Background(), "PDM和PLM系统的区别", r, SearchFilter{}, searchOptions{}, planner, AgentConfig{SubQuestions: 3, MaxSteps: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 与原问题相同的子问题和重复的追加检索不再检索，重复的查询结束追加检索
+	if want := []string{"PDM和PLM系统的区别", "什么是PDM系统", "什么是PLM系统", "PLM 生命周期阶段"}; !reflect.DeepEqual(r.queries, want) {
+		t.Errorf("queries = %q, want %q", r.queries, want)
+	}
+	if want := []string{"什么是PDM系统", "什么是PLM系统"}; !reflect.DeepEqual(trace.SubQuestions, want) {
+		t.Errorf("sub-questions = %q, want %q", trace.SubQuestions, want)
+	}
+	var kinds []string
+	for _, s := range trace.Steps {
+		kinds = append(kinds, fmt.Sprintf("%s:%d/%d", s.Kind, s.New, s.Hits))
+	}
+	if want := []string{"original:1/1", "sub_question:1/2", "sub_question:1/1", "tool:1/1"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("steps = %v, want %v", kinds, want)
+	}
+
+	// 结果是所有检索的并集，重复的片段保留最高得分
+	var ids []int
+	for _, h := range result.Hits {
+		ids = append(ids, h.Chunk.ID)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2, 3, 4}) || result.Hits[0].Score != 0.05 {
+		t.Errorf("unexpected hits %v (score %v)", ids, result.Hits[0].Score)
+	}
+	if result.Mode != modeAgent || result.QueryType.Name != "比较性" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	// 追加检索在同一轮对话中进行：第一次只有包含片段摘录的用户消息，
+	// 之后依次追加模型的工具调用和检索结果
+	if len(planner.histories) != 2 {
+		t.Fatalf("expected 2 follow-up calls, got %d", len(planner.histories))
+	}
+	evidence := planner.histories[0][0].Content
+	if !strings.Contains(evidence, `检索 "什么是PLM系统": 找到 1 个片段`) || !strings.Contains(evidence, "- plm.md: chunk 3 of plm.md") {
+		t.Errorf("unexpected evidence:\n%s", evidence)
+	}
+	second := planner.histories[1]
+	if len(second) != 3 || second[1].Role != "assistant" || len(second[1].ToolCalls) != 1 || second[2].Role != "tool" || second[2].ToolName != agentSearchTool {
+		t.Fatalf("unexpected history %+v", second)
+	}
+	if !strings.Contains(second[2].Content, `检索 "PLM 生命周期阶段" 找到 1 个片段，其中 1 个是新的`) || !strings.Contains(second[2].Content, "chunk 4 of plm.md") {
+		t.Errorf("unexpected tool result:\n%s", second[2].Content)
+	}
+}
+
+func TestRunAgentPlannerFailure(t *testing.T) {
+	r := &queryRetriever{hits: map[string][]*SearchHit{"q": {agentHit(1, "a.md", 0.03)}}}
+	planner := &scriptedPlanner{decomposeErr: errors.New("model not found"), followUps: []string{"more"}}
+	result, trace, err := runAgent(context.Background(), "q", r, SearchFilter{}, searchOptions{}, planner, AgentConfig{SubQuestions: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// max_steps为0时不追加检索
+	if len(trace.Steps) != 1 || len(result.Hits) != 1 || len(planner.histories) != 0 {
+		t.Errorf("expected the original search only, got %+v", trace)
+	}
+}
+
+func TestParseSubQuestions(t *testing.T) {
+	reply := "1. 什么是PDM系统？\n2. 什么是PLM系统？\n\n- 什么是PDM系统？\n3. 两者如何集成？"
+	if got, want := parseSubQuestions(reply, 2), []string{"什么是PDM系统？", "什么是PLM系统？"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSubQuestions() = %q, want %q", got, want)
+	}
+}
+
+func TestOllamaPlannerFollowUp(t *testing.T) {
+	var req OllamaToolRequest
+	reply := `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"search_docs","arguments":{"query":"PLM 生命周期"}}}]},"done":true}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintln(w, reply)
+	}))
+	defer srv.Close()
+
+	p := &ollamaPlanner{url: srv.URL, model: "m", numCtx: 4096}
+	history := []OllamaToolMessage{{Role: "user", Content: "- pdm.md: PDM管理设计数据"}}
+	msg, err := p.FollowUp(context.Background(), history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call, ok := searchCall(msg); !ok || call.Function.Arguments["query"] != "PLM 生命周期" || msg.Role != "assistant" {
+		t.Fatalf("FollowUp() = %+v", msg)
+	}
+	if req.Stream || len(req.Tools) != 1 || req.Tools[0].Function.Name != agentSearchTool || !strings.Contains(req.Messages[0].Content, "- pdm.md") {
+		t.Errorf("unexpected request %+v", req)
+	}
+
+	// 工具调用和检索结果随对话一起发送
+	history = append(history, msg, OllamaToolMessage{Role: "tool", ToolName: agentSearchTool, Content: "- plm.md: PLM覆盖全生命周期"})
+	reply = `{"message":{"role":"assistant","content":"足够"},"done":true}`
+	msg, err = p.FollowUp(context.Background(), history)
+	if _, ok := searchCall(msg); err != nil || ok {
+		t.Errorf("FollowUp() = %+v, %v, want no tool call", msg, err)
+	}
+	if len(req.Messages) != 3 || len(req.Messages[1].ToolCalls) != 1 || req.Messages[2].Role != "tool" || req.Messages[2].ToolName != agentSearchTool {
+		t.Errorf("unexpected messages %+v", req.Messages)
+	}
+}
+
+func TestAskCmdAgent(t *testing.T) {
+	useTempQACache(t)
+	cmd, gen, out, cli := newAskTest("PDM管理设计数据[1]，PLM覆盖产品全生命周期[2]。")
+	cmd.Text = "PDM和PLM系统的区别"
+	cmd.Agent = true
+	cmd.Verbose = true
+	cmd.Expand = 0
+	cmd.retriever = &queryRetriever{hits: map[string][]*SearchHit{
+		"什么是PDM系统": {agentHit(1, "pdm.md", 0.03)},
+		"什么是PLM系统": {agentHit(2, "plm.md", 0.03)},
+	}}
+	cmd.planner = &scriptedPlanner{subQuestions: []string{"什么是PDM系统", "什么是PLM系统"}}
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+	// 回答由两个子问题检索结果的并集生成
+	if len(gen.prompts) != 1 || !strings.Contains(gen.prompts[0], "From file: pdm.md") || !strings.Contains(gen.prompts[0], "From file: plm.md") {
+		t.Errorf("expected both sides in the prompt: %q", gen.prompts)
+	}
+	for _, want := range []string{"代理检索: 3 次检索合并为 2 个片段", "🧭 代理检索: 2 个子问题", "[3] sub_question"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestOllamaPlannerDecomposeUsesGenerator(t *testing.T) {
+	useTempQACache(t)
+	cfg := &Config{}
+	cfg.Ollama.ChatModel = "scripted"
+	cfg.applyDefaults()
+	gen := &scriptedGenerator{answers: []string{"1. 什么是PDM？\n2. 什么是PLM？"}}
+	p, err := newOllamaPlanner(cfg, gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	questions, err := p.Decompose(context.Background(), "PDM和PLM的区别？", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 2 || len(gen.prompts) != 1 {
+		t.Fatalf("Decompose() = %q after %d generations", questions, len(gen.prompts))
+	}
+	// 第二次拆分来自问答缓存
+	if _, err := p.Decompose(context.Background(), "PDM和PLM的区别？", 3); err != nil || len(gen.prompts) != 1 {
+		t.Errorf("expected a cached decomposition, got %d generations, err %v", len(gen.prompts), err)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/rotemtam/entrag/ent"
+)
+
+// staticRetriever 返回固定检索结果的Retriever
+type staticRetriever struct {
+	result    *retrievalResult
+	neighbors []*ent.Chunk
+}
+
+// Search 返回固定的检索结果
+func (r *staticRetriever) Search(ctx context.Context, question string, filter SearchFilter, opts searchOptions) (*retrievalResult, error) {
+	return r.result, nil
+}
+
+// Neighbors 返回固定的邻居chunk
+func (r *staticRetriever) Neighbors(ctx context.Context, hits []*SearchHit, window int) ([]*ent.Chunk, error) {
+	return r.neighbors, nil
+}
+
+// Embed 没有向量模型，抽取式回答只按词汇重叠排序
+func (r *staticRetriever) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, errors.New("no embedding model")
+}
+
+// newAskTest 返回使用假检索和假模型的ask命令及其输出
+func newAskTest(answers ...string) (*AskCmd, *scriptedGenerator, *bytes.Buffer, *CLI) {
+	cfg := &Config{}
+	cfg.Ollama.ChatModel = "fake"
+	cfg.applyDefaults()
+
+	hit := &SearchHit{
+		Chunk:    &ent.Chunk{ID: 2, Path: "data/ent/intro.md", Nchunk: 1, Data: "Ent is an entity framework for Go.", StartLine: 3, EndLine: 5},
+		Distance: 0.2,
+		Score:    0.03,
+	}
+	retriever := &staticRetriever{
+		result: &retrievalResult{
+			Hits:      []*SearchHit{hit},
+			QueryType: QueryTypeConfig{Name: "概念性", Prompt: "concept"},
+			Details:   "从 1 个候选中智能选择了 1 个高质量片段",
+		},
+		neighbors: []*ent.Chunk{
+			{ID: 1, Path: "data/ent/intro.md", Nchunk: 0, Data: "# Introduction", StartLine: 1, EndLine: 2},
+			hit.Chunk,
+		},
+	}
+	gen := &scriptedGenerator{answers: answers}
+	var out bytes.Buffer
+	cmd := &AskCmd{
+		Text:        "What is Ent?",
+		Expand:      1,
+		retriever:   retriever,
+		generator:   gen,
+		countTokens: func(s string) int { return len(strings.Fields(s)) },
+		stdout:      &out,
+	}
+	return cmd, gen, &out, &CLI{cfg: cfg}
+}
+
+func TestAskCmd(t *testing.T) {
+	useTempQACache(t)
+	cmd, gen, out, cli := newAskTest("Ent is an entity framework [1].")
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+
+	// prompt使用英文模板，包含命中chunk及其邻居
+	if len(gen.prompts) != 1 {
+		t.Fatalf("expected one generation, got %d", len(gen.prompts))
+	}
+	prompt := gen.prompts[0]
+	for _, want := range []string{"Question: What is Ent?", "[1] From file: data/ent/intro.md (L1-5)", "# Introduction", "Ent is an entity framework for Go."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, prompt)
+		}
+	}
+
+	output := out.String()
+	for _, want := range []string{"💬 回答:\nEnt is an entity framework [1].", "✓ [1] data/ent/intro.md", "已缓存问答结果"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+
+	// 相同的问题第二次从问答缓存返回，不再调用模型
+	cmd2, gen2, out2, cli2 := newAskTest()
+	if err := cmd2.Run(cli2); err != nil {
+		t.Fatal(err)
+	}
+	if len(gen2.prompts) != 0 {
+		t.Errorf("expected a cache hit, generator was called %d times", len(gen2.prompts))
+	}
+	if !strings.Contains(out2.String(), "来自问答缓存") {
+		t.Errorf("expected a cached answer:\n%s", out2.String())
+	}
+}
+
+func TestAskCmdNoHits(t *testing.T) {
+	useTempQACache(t)
+	cmd, gen, out, cli := newAskTest()
+	cmd.retriever.(*staticRetriever).result.Hits = nil
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+	if len(gen.prompts) != 0 {
+		t.Error("the model must not be called without relevant passages")
+	}
+	if !strings.Contains(out.String(), "📭") {
+		t.Errorf("expected the no-content message:\n%s", out.String())
+	}
+}
+
+func TestAskCmdGenerationError(t *testing.T) {
+	useTempQACache(t)
+	cmd, _, _, cli := newAskTest()
+	fallback := false
+	cli.cfg.Extractive.Fallback = &fallback
+	if err := cmd.Run(cli); err == nil {
+		t.Fatal("expected the generator error to be returned")
+	}
+	if qaCache.Size() != 0 {
+		t.Error("a failed answer must not be cached")
+	}
+}
+
+func TestAskCmdExtractiveFallback(t *testing.T) {
+	useTempQACache(t)
+	cmd, gen, out, cli := newAskTest()
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+	if len(gen.prompts) != 1 {
+		t.Errorf("expected one failed generation, got %d", len(gen.prompts))
+	}
+	output := out.String()
+	for _, want := range []string{"改用抽取式回答", "- **Ent** is an entity framework for Go. [1]", "✓ [1] data/ent/intro.md", "抽取式回答完成"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output does not contain %q:\n%s", want, output)
+		}
+	}
+	if qaCache.Size() != 0 {
+		t.Error("an extractive answer must not be cached")
+	}
+
+	// --extractive 不调用模型
+	cmd, gen, out, cli = newAskTest()
+	cmd.Extractive = true
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+	if len(gen.prompts) != 0 || strings.Contains(out.String(), "改用抽取式回答") {
+		t.Errorf("expected no generation, got %d prompts:\n%s", len(gen.prompts), out.String())
+	}
+}
+
+func TestAskCmdCodeSyntaxErrors(t *testing.T) {
+	useTempQACache(t)
+	cmd, _, out, cli := newAskTest("示例[1]:\n\n```go\nclient.User.\n\tCreate(\n```")
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+	// 输出不是终端时，标注了语法错误的代码块紧跟在回答之后
+	output := out.String()
+	annotated := strings.Index(output, "🧪 代码块1 的语法错误:")
+	if annotated < 0 || !strings.Contains(output[annotated:], "\tCreate(\n\t// ❌ 语法错误: ") || annotated > strings.Index(output, "回答完成") {
+		t.Errorf("expected the annotated block right after the answer:\n%s", output)
+	}
+}
+
+func TestAskCmdJSON(t *testing.T) {
+	useTempQACache(t)
+	cmd, _, out, cli := newAskTest("Ent is an entity framework [1][3].")
+	var progress bytes.Buffer
+	cmd.Format = "json"
+	cmd.Verify = true
+	cmd.stderr = &progress
+	// 只有全文检索命中的片段距离为+Inf
+	cmd.retriever.(*staticRetriever).result.Hits[0].Distance = math.Inf(1)
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("stdout is not a JSON document: %v\n%s", err, out.String())
+	}
+	if doc["question"] != "What is Ent?" || doc["query_type"] != "概念性" || doc["answer"] != "Ent is an entity framework [1][3]." || doc["answer_mode"] != "generated" {
+		t.Errorf("unexpected document %v", doc)
+	}
+	citations := doc["citations"].([]any)
+	if len(citations) != 1 {
+		t.Fatalf("expected one citation, got %v", citations)
+	}
+	c := citations[0].(map[string]any)
+	if c["chunk_id"] != 2.0 || c["path"] != "data/ent/intro.md" || c["nchunk"] != 1.0 || c["cited"] != true || c["distance"] != nil {
+		t.Errorf("unexpected citation %v", c)
+	}
+	if invalid := doc["invalid_citations"].([]any); len(invalid) != 1 || invalid[0] != 3.0 {
+		t.Errorf("unexpected invalid citations %v", invalid)
+	}
+	models := doc["models"].(map[string]any)
+	if models["chat"] != "scripted" || models["generator"] != "ollama" {
+		t.Errorf("unexpected models %v", models)
+	}
+	if cache := doc["cache"].(map[string]any); cache["answer"] != false {
+		t.Errorf("unexpected cache flags %v", cache)
+	}
+	// 回答中的句子与段落词汇重叠，事实核查通过
+	if doc["groundedness"] != 1.0 || doc["grounding"].(map[string]any)["method"] != "lexical" {
+		t.Errorf("unexpected grounding %v %v", doc["groundedness"], doc["grounding"])
+	}
+	if _, ok := doc["timings_ms"].(map[string]any)["total"]; !ok {
+		t.Errorf("missing total timing: %v", doc["timings_ms"])
+	}
+	if !strings.Contains(progress.String(), "⏳ 正在搜索相关文档") {
+		t.Errorf("expected progress on stderr, got:\n%s", progress.String())
+	}
+}
+
+func TestAskCmdNoTokenBudget(t *testing.T) {
+	useTempQACache(t)
+	cmd, gen, _, cli := newAskTest("unused")
+	// 通过--num-ctx覆盖后回答预留占满了上下文窗口
+	cli.cfg.Ollama.Options.NumCtx = cli.cfg.Ollama.AnswerTokens
+	err := cmd.Run(cli)
+	if err == nil || !strings.Contains(err.Error(), "no token budget left") {
+		t.Fatalf("expected a budget error, got %v", err)
+	}
+	if len(gen.prompts) != 0 {
+		t.Error("the model must not be asked without passages")
+	}
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// sessionDir 保存对话会话的目录
+var sessionDir = filepath.Join(".entrag_cache", "sessions")
+
+type (
+	// ChatCmd groups the interactive chat subcommands.
+	ChatCmd struct {
+		Start  ChatStartCmd  `kong:"cmd,default='withargs',help='Start or resume an interactive chat session.'"`
+		List   ChatListCmd   `kong:"cmd,help='List saved chat sessions.'"`
+		Export ChatExportCmd `kong:"cmd,help='Export a chat session as Markdown.'"`
+	}
+	// ChatStartCmd runs the chat REPL.
+	ChatStartCmd struct {
+		Resume  string `help:"ID of a saved session to resume."`
+		Mode    string `help:"Retrieval mode: single, multi_query or hyde (defaults to retrieval.mode)." enum:",single,multi_query,hyde" default:""`
+		Verbose bool   `short:"v" help:"Show retrieval details such as rewritten questions and query variants."`
+		Lang    string `help:"Answer language (e.g. zh, en); detected from each question by default."`
+		// CrossLingual enables retrieval.cross_lingual for every question.
+		CrossLingual bool `help:"Also search each question translated into the other corpus languages."`
+
+		Filter SearchFilter `embed:""`
+		// Generation overrides ollama.options for this session.
+		Generation GenerationOptions `embed:""`
+	}
+	// ChatListCmd lists the saved sessions.
+	ChatListCmd struct {
+	}
+	// ChatExportCmd writes a session as Markdown.
+	ChatExportCmd struct {
+		ID     string `kong:"arg,required,help='Session ID.'"`
+		Output string `short:"o" help:"Output file (defaults to stdout)."`
+	}
+)
+
+// ChatMessage 对话中的一条消息
+type ChatMessage struct {
+	Role    string `json:"role"` // user / assistant
+	Content string `json:"content"`
+	// Query 为用户追问改写后的独立问题，仅user消息
+	Query string `json:"query,omitempty"`
+	// Sources 为回答引用的文件，仅assistant消息
+	Sources []string `json:"sources,omitempty"`
+}
+
+// ChatSession 一次持久化的对话
+type ChatSession struct {
+	ID       string        `json:"id"`
+	Model    string        `json:"model"`
+	Created  time.Time     `json:"created"`
+	Updated  time.Time     `json:"updated"`
+	Messages []ChatMessage `json:"messages"`
+}
+
+// newChatSession 创建以当前时间加随机后缀命名的会话，
+// 同一秒内启动的多个会话不会互相覆盖
+func newChatSession(model string) *ChatSession {
+	now := time.Now()
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	id := now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+	return &ChatSession{ID: id, Model: model, Created: now, Updated: now}
+}
+
+// sessionPath 返回会话文件路径，拒绝包含路径分隔符或".."的ID，
+// 避免--resume和export读写会话目录之外的文件
+func sessionPath(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("invalid session id %q", id)
+	}
+	return filepath.Join(sessionDir, id+".json"), nil
+}
+
+// loadChatSession 读取保存的会话
+func loadChatSession(id string) (*ChatSession, error) {
+	path, err := sessionPath(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session %q not found", id)
+		}
+		return nil, err
+	}
+	var s ChatSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %q: %w", id, err)
+	}
+	return &s, nil
+}
+
+// Save 将会话写入磁盘
+func (s *ChatSession) Save() error {
+	path, err := sessionPath(s.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		return fmt.Errorf("failed to create session directory: %v", err)
+	}
+	s.Updated = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Turns 返回问答轮数
+func (s *ChatSession) Turns() int {
+	n := 0
+	for _, m := range s.Messages {
+		if m.Role == "user" {
+			n++
+		}
+	}
+	return n
+}
+
+// Title 返回会话的第一个问题
+func (s *ChatSession) Title() string {
+	for _, m := range s.Messages {
+		if m.Role == "user" {
+			return m.Content
+		}
+	}
+	return ""
+}
+
+// Markdown 将会话导出为Markdown
+func (s *ChatSession) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 对话 %s\n\n", s.ID)
+	fmt.Fprintf(&b, "- 模型: %s\n- 开始: %s\n- 更新: %s\n", s.Model, s.Created.Format(time.DateTime), s.Updated.Format(time.DateTime))
+	turn := 0
+	for _, m := range s.Messages {
+		switch m.Role {
+		case "user":
+			turn++
+			fmt.Fprintf(&b, "\n## %d. %s\n\n", turn, m.Content)
+			if m.Query != "" && m.Query != m.Content {
+				fmt.Fprintf(&b, "> 检索问题: %s\n\n", m.Query)
+			}
+		case "assistant":
+			b.WriteString(strings.TrimSpace(m.Content))
+			b.WriteString("\n")
+			if len(m.Sources) > 0 {
+				b.WriteString("\n**来源:**\n\n")
+				for _, src := range m.Sources {
+					fmt.Fprintf(&b, "- %s\n", src)
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// listChatSessions 返回所有保存的会话，最近更新的在前
+func listChatSessions() ([]*ChatSession, error) {
+	entries, err := os.ReadDir(sessionDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sessions []*ChatSession
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		s, err := loadChatSession(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].Updated.Equal(sessions[j].Updated) {
+			return sessions[i].Updated.After(sessions[j].Updated)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	return sessions, nil
+}
+
+// rewritePrompt 将追问改写为独立问题的prompt
+const rewritePrompt = `下面是用户与文档问答助手的对话记录，以及用户的最新问题。
+请将最新问题改写为一个不依赖对话记录、可以单独用于检索文档的完整问题：补全其中的代词和省略的主题，保持原问题的语言。
+如果最新问题本身已经完整，原样输出。只输出改写后的问题，不要输出其他内容。
+
+对话记录:
+%s
+最新问题: %s
+
+独立问题:`
+
+// rewriteTurns 改写追问时参考的最近问答轮数
+const rewriteTurns = 3
+
+// rewriteFollowUp 结合对话历史将追问改写为独立问题，没有历史时原样返回
+func rewriteFollowUp(history []ChatMessage, question string, generate func(prompt string) (string, error)) (string, error) {
+	if len(history) == 0 {
+		return question, nil
+	}
+	// 只保留最近几轮
+	start, users := len(history), 0
+	for start > 0 && users < rewriteTurns {
+		start--
+		if history[start].Role == "user" {
+			users++
+		}
+	}
+	var b strings.Builder
+	for _, m := range history[start:] {
+		role := "用户"
+		if m.Role == "assistant" {
+			role = "助手"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", role, truncateRunes(strings.TrimSpace(m.Content), 500))
+	}
+	reply, err := generate(fmt.Sprintf(rewritePrompt, b.String(), question))
+	if err != nil {
+		return question, err
+	}
+	rewritten := strings.TrimSpace(strings.SplitN(strings.TrimSpace(reply), "\n", 2)[0])
+	if rewritten == "" {
+		return question, nil
+	}
+	return rewritten, nil
+}
+
+// truncateRunes 截断过长的文本
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+// historyMessages 从最近的消息开始，在maxTokens以内选取历史消息，
+// 并保证以完整的问答轮次开始
+func historyMessages(history []ChatMessage, maxTokens int, countTokens func(string) int) []ChatMessage {
+	start, used := len(history), 0
+	for i := len(history) - 1; i >= 0; i-- {
+		used += countTokens(history[i].Content)
+		if used > maxTokens {
+			break
+		}
+		if history[i].Role == "user" {
+			start = i
+		}
+	}
+	return history[start:]
+}
+
+// chatSystemPrompt 对话的系统消息
+const chatSystemPrompt = "你是一个文档问答助手，基于用户提供的文档内容回答问题，并结合之前的对话理解用户的追问。文档中没有的信息请明确说明。"
+
+// Run is the method called when the "chat" command is executed.
+func (cmd *ChatStartCmd) Run(ctx *CLI) error {
+	cfg := ctx.LoadedConfig()
+	cfg.Ollama.Options = cfg.Ollama.Options.Merge(cmd.Generation)
+	model := cfg.Generator.Model
+	session := newChatSession(model)
+	if cmd.Resume != "" {
+		var err error
+		if session, err = loadChatSession(cmd.Resume); err != nil {
+			return err
+		}
+	}
+	client, err := ctx.entClient()
+	if err != nil {
+		return fmt.Errorf("failed opening connection to postgres: %w", err)
+	}
+	generator, err := newGenerator(cfg)
+	if err != nil {
+		return err
+	}
+	retriever := &dbRetriever{client: client, cfg: cfg, generator: generator}
+	countTokens, err := tokenCounter(cfg.App.TokenEncoding)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Resume != "" {
+		fmt.Printf("📂 继续对话 %s (%d 轮)\n", session.ID, session.Turns())
+	} else {
+		fmt.Printf("💬 新对话 %s\n", session.ID)
+	}
+
+	fmt.Println("   输入问题开始对话，输入 /exit 退出")
+	if !cmd.Filter.Empty() {
+		fmt.Printf("🔎 过滤条件: %s\n", cmd.Filter)
+	}
+
+	modelCfg := cfg.Ollama.ChatModelConfig(model)
+	// 改写追问不使用回答的生成参数（如stop）
+	generate := func(prompt string) (string, error) {
+		return generator.Generate(context.Background(), prompt, GenerationOptions{}, nil)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for {
+		fmt.Print("\n🙋 > ")
+		if !scanner.Scan() {
+			fmt.Println()
+			break
+		}
+		question := strings.TrimSpace(scanner.Text())
+		if question == "" {
+			continue
+		}
+		if question == "/exit" || question == "/quit" {
+			break
+		}
+
+		// 1. 将追问改写为独立问题
+		query, err := rewriteFollowUp(session.Messages, question, generate)
+		if err != nil {
+			fmt.Printf("⚠️  改写追问失败，直接使用原问题: %v\n", err)
+		}
+		if cmd.Verbose && query != question {
+			fmt.Printf("   ✏️  检索问题: %s\n", query)
+		}
+
+		// 2. 检索
+		result, err := retriever.Search(context.Background(), query, cmd.Filter, searchOptions{Mode: cmd.Mode, CrossLingual: cmd.CrossLingual})
+		if err != nil {
+			fmt.Printf("❌ 检索失败: %v\n", err)
+			continue
+		}
+		if cmd.Verbose {
+			fmt.Printf("   📊 %s\n", result.Details)
+			printVariants(os.Stdout, result)
+			printHits(os.Stdout, result.Hits)
+		}
+
+		// 3. 在上下文窗口内装入历史与检索内容：历史最多占四分之一
+		reserved := countTokens(chatSystemPrompt)
+		history := historyMessages(session.Messages, (modelCfg.NumCtx-modelCfg.AnswerTokens-reserved)/4, countTokens)
+		for _, m := range history {
+			reserved += countTokens(m.Content)
+		}
+		// 回答语言取自用户的原问题，而不是改写后的检索问题
+		lang := cmd.Lang
+		if lang == "" {
+			lang = detectLanguage(question)
+		}
+		opts := promptOptions{Collection: cmd.Filter.Collection, Lang: lang, Window: cfg.Retrieval.NeighborWindow, Reserved: reserved}
+		prepared, err := buildAnswerPrompt(context.Background(), retriever, query, result, opts, countTokens, cfg)
+		if err != nil {
+			fmt.Printf("❌ 构建上下文失败: %v\n", err)
+			continue
+		}
+		passages := prepared.Passages
+		prompt := prepared.Prompt
+		if len(passages) == 0 {
+			prompt = fmt.Sprintf("文档库中没有找到与问题相关的内容。请结合之前的对话回答，并说明文档中没有相关信息。\n\n问题: %s", query)
+		}
+
+		// 4. 生成回答
+		messages := []ChatMessage{{Role: "system", Content: chatSystemPrompt}}
+		for _, m := range history {
+			messages = append(messages, ChatMessage{Role: m.Role, Content: m.Content})
+		}
+		messages = append(messages, ChatMessage{Role: "user", Content: prompt})
+		fmt.Print("⏳ 正在生成回答...")
+		start := time.Now()
+		answer, err := generator.Chat(context.Background(), messages, cfg.Ollama.Options, nil)
+		if err != nil {
+			fmt.Printf("\n❌ 生成回答失败: %v\n", err)
+			continue
+		}
+		fmt.Printf(" 完成 (⏱️ %v)\n", time.Since(start))
+
+		codeChecks := checkCode(answer, passages)
+		annotated := annotateCode(answer, codeChecks)
+		out, err := glamour.Render(annotated, "dark")
+		if err != nil {
+			out = annotated
+		}
+		fmt.Print(out)
+
+		printSources(os.Stdout, passages, checkCitations(answer, len(passages)))
+		printCodeChecks(os.Stdout, codeChecks)
+		var sources []string
+		for i, p := range passages {
+			sources = append(sources, fmt.Sprintf("[%d] %s", i+1, sourceLabel(p)))
+		}
+
+		// 5. 保存会话
+		session.Messages = append(session.Messages,
+			ChatMessage{Role: "user", Content: question, Query: query},
+			ChatMessage{Role: "assistant", Content: answer, Sources: sources},
+		)
+		if err := session.Save(); err != nil {
+			fmt.Printf("⚠️  保存会话失败: %v\n", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading input: %v", err)
+	}
+
+	if session.Turns() > 0 {
+		fmt.Printf("💾 会话已保存，使用 entrag chat --resume %s 继续\n", session.ID)
+	}
+	return nil
+}
+
+// Run is the method called when the "chat list" command is executed.
+func (cmd *ChatListCmd) Run(ctx *CLI) error {
+	sessions, err := listChatSessions()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("📭 没有保存的对话")
+		return nil
+	}
+	fmt.Printf("📂 共 %d 个对话:\n", len(sessions))
+	for _, s := range sessions {
+		fmt.Printf("   %s  %s  %2d 轮  %s\n", s.ID, s.Updated.Format(time.DateTime), s.Turns(), truncateRunes(s.Title(), 40))
+	}
+	return nil
+}
+
+// Run is the method called when the "chat export" command is executed.
+func (cmd *ChatExportCmd) Run(ctx *CLI) error {
+	session, err := loadChatSession(cmd.ID)
+	if err != nil {
+		return err
+	}
+	if cmd.Output == "" {
+		fmt.Print(session.Markdown())
+		return nil
+	}
+	if err := os.WriteFile(cmd.Output, []byte(session.Markdown()), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("✅ 已导出到 %s\n", cmd.Output)
+	return nil
+}
+
+// OllamaMessagesRequest is the request of Ollama's /api/chat endpoint.
+type OllamaMessagesRequest struct {
+	Model    string         `json:"model"`
+	Messages []ChatMessage  `json:"messages"`
+	Stream   bool           `json:"stream"`
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+// OllamaMessagesResponse is the response of Ollama's /api/chat endpoint.
+type OllamaMessagesResponse struct {
+	Message ChatMessage `json:"message"`
+	Done    bool        `json:"done"`
+	Error   string      `json:"error,omitempty"`
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteFollowUp(t *testing.T) {
+	var prompt string
+	generate := func(p string) (string, error) {
+		prompt = p
+		return "How do I define edges in an ent schema?\nextra line", nil
+	}
+
+	got, err := rewriteFollowUp(nil, "what is ent?", generate)
+	if err != nil || got != "what is ent?" || prompt != "" {
+		t.Fatalf("first question should not be rewritten: %q (%v)", got, err)
+	}
+
+	history := []ChatMessage{
+		{Role: "user", Content: "How do I define a schema in ent?"},
+		{Role: "assistant", Content: "Create a type embedding ent.Schema."},
+	}
+	got, err = rewriteFollowUp(history, "and how do I do that with edges?", generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "How do I define edges in an ent schema?" {
+		t.Errorf("unexpected rewrite %q", got)
+	}
+	if !strings.Contains(prompt, "Create a type embedding ent.Schema.") || !strings.Contains(prompt, "and how do I do that with edges?") {
+		t.Errorf("prompt is missing the history or question:\n%s", prompt)
+	}
+}
+
+func TestHistoryMessages(t *testing.T) {
+	history := []ChatMessage{
+		{Role: "user", Content: "aaaa"},
+		{Role: "assistant", Content: "bbbb"},
+		{Role: "user", Content: "cc"},
+		{Role: "assistant", Content: "dd"},
+	}
+	count := func(s string) int { return len(s) }
+	if got := historyMessages(history, 100, count); len(got) != 4 {
+		t.Errorf("expected the whole history, got %d messages", len(got))
+	}
+	// 预算只够最后一轮时不能从assistant消息开始
+	if got := historyMessages(history, 7, count); len(got) != 2 || got[0].Content != "cc" {
+		t.Errorf("expected the last turn only, got %+v", got)
+	}
+	if got := historyMessages(history, 1, count); len(got) != 0 {
+		t.Errorf("expected no history, got %+v", got)
+	}
+}
+
+func TestChatSessionPersistence(t *testing.T) {
+	old := sessionDir
+	sessionDir = t.TempDir()
+	defer func() { sessionDir = old }()
+
+	s := newChatSession("llama3.2:3b")
+	s.Messages = []ChatMessage{
+		{Role: "user", Content: "What is ent?", Query: "What is ent?"},
+		{Role: "assistant", Content: "An entity framework.", Sources: []string{"data/ent.md #0-1"}},
+		{Role: "user", Content: "and edges?", Query: "What are edges in ent?"},
+		{Role: "assistant", Content: "Relations between entities."},
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := listChatSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Turns() != 2 || sessions[0].Title() != "What is ent?" {
+		t.Fatalf("unexpected sessions %+v", sessions)
+	}
+
+	loaded, err := loadChatSession(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := loaded.Markdown()
+	for _, want := range []string{"## 1. What is ent?", "## 2. and edges?", "> 检索问题: What are edges in ent?", "- data/ent.md #0-1"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "> 检索问题: What is ent?") {
+		t.Errorf("unchanged queries should not be repeated:\n%s", md)
+	}
+}
+
+func TestChatSessionIDsAreUnique(t *testing.T) {
+	old := sessionDir
+	sessionDir = t.TempDir()
+	defer func() { sessionDir = old }()
+
+	// 同一秒内创建的会话不能互相覆盖
+	a, b := newChatSession("llama3.2:3b"), newChatSession("llama3.2:3b")
+	if a.ID == b.ID {
+		t.Fatalf("expected distinct session ids, got %q twice", a.ID)
+	}
+	for _, s := range []*ChatSession{a, b} {
+		if err := s.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessions, err := listChatSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("expected 2 saved sessions, got %d", len(sessions))
+	}
+}
+
+func TestSessionPathRejectsTraversal(t *testing.T) {
+	for _, id := range []string{"", "../config", "../../etc/passwd", "a/b", `a\b`, ".."} {
+		if _, err := sessionPath(id); err == nil {
+			t.Errorf("sessionPath(%q) should fail", id)
+		}
+		if _, err := loadChatSession(id); err == nil {
+			t.Errorf("loadChatSession(%q) should fail", id)
+		}
+	}
+	if _, err := sessionPath("20240101-120000-a1b2c3"); err != nil {
+		t.Errorf("unexpected error for a valid id: %v", err)
+	}
+}
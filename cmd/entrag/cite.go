@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// citationPattern 匹配回答中的引用，如 [1]、[2, 3] 或 [2][3]
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*[,，、]\s*\d+)*)\]`)
+
+// inlineCode 匹配行内代码，如 `args[0]`
+var inlineCode = regexp.MustCompile("`[^`\n]*`")
+
+// sourceLabel 返回段落来源的可读描述：路径、标题和行号范围
+func sourceLabel(p *Passage) string {
+	label := p.Path
+	if p.Heading != "" {
+		label += " § " + p.Heading
+	}
+	if p.StartLine > 0 {
+		label += fmt.Sprintf(" (L%d-%d)", p.StartLine, p.EndLine)
+	}
+	return label
+}
+
+// citationCheck 回答中引用的校验结果
+type citationCheck struct {
+	Cited   []int // 有效的引用编号，按首次出现的顺序
+	Invalid []int // 没有对应段落的引用编号
+}
+
+// IsCited 判断第n个段落是否被引用
+func (c citationCheck) IsCited(n int) bool {
+	for _, m := range c.Cited {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCode 去除回答中的代码块和行内代码，其中的下标（如 args[0]）不是引用
+func stripCode(answer string) string {
+	var b strings.Builder
+	inFence := false
+	for _, line := range strings.Split(answer, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			b.WriteString(inlineCode.ReplaceAllString(line, ""))
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// checkCitations 提取回答中（代码以外）的引用编号，并与提供给模型的段落数核对
+func checkCitations(answer string, passages int) citationCheck {
+	var check citationCheck
+	seen := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(stripCode(answer), -1) {
+		for _, field := range strings.FieldsFunc(m[1], func(r rune) bool {
+			return r == ',' || r == '，' || r == '、' || r == ' '
+		}) {
+			n, err := strconv.Atoi(field)
+			if err != nil || seen[n] {
+				continue
+			}
+			seen[n] = true
+			if n >= 1 && n <= passages {
+				check.Cited = append(check.Cited, n)
+			} else {
+				check.Invalid = append(check.Invalid, n)
+			}
+		}
+	}
+	return check
+}
+
+// printSources 输出回答的来源列表，并标出无效的引用
+func printSources(w io.Writer, passages []*Passage, check citationCheck) {
+	if len(passages) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "📚 来源:")
+	for i, p := range passages {
+		mark := " "
+		if check.IsCited(i + 1) {
+			mark = "✓"
+		}
+		fmt.Fprintf(w, "   %s [%d] %s 得分: %.4f, 距离: %.4f\n", mark, i+1, sourceLabel(p), p.Score, p.Distance)
+	}
+	if len(check.Cited) == 0 {
+		fmt.Fprintln(w, "   ⚠️  回答没有引用任何来源")
+	}
+	if len(check.Invalid) > 0 {
+		refs := make([]string, len(check.Invalid))
+		for i, n := range check.Invalid {
+			refs[i] = fmt.Sprintf("[%d]", n)
+		}
+		fmt.Fprintf(w, "   ⚠️  回答中的引用 %s 没有对应的文档段落\n", strings.Join(refs, " "))
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckCitations(t *testing.T) {
+	answer := "Ent是一个ORM框架[1]。它使用代码生成[2, 3]，支持钩子[2][7]。见 [0] 和 [1，3]。数组下标 a[i] 不是引用。"
+	check := checkCitations(answer, 3)
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(check.Cited, want) {
+		t.Errorf("Cited = %v, want %v", check.Cited, want)
+	}
+	if want := []int{7, 0}; !reflect.DeepEqual(check.Invalid, want) {
+		t.Errorf("Invalid = %v, want %v", check.Invalid, want)
+	}
+	if !check.IsCited(2) || check.IsCited(4) {
+		t.Errorf("IsCited mismatch: %+v", check)
+	}
+}
+
+func TestCheckCitationsIgnoresCode(t *testing.T) {
+	answer := "使用 `os.Args[1]` 读取参数[1]：\n\n```go\nfunc main() {\n\tfmt.Println(args[0], s[2])\n}\n```\n\n见 [2]。"
+	check := checkCitations(answer, 1)
+	if want := []int{1}; !reflect.DeepEqual(check.Cited, want) {
+		t.Errorf("Cited = %v, want %v", check.Cited, want)
+	}
+	if want := []int{2}; !reflect.DeepEqual(check.Invalid, want) {
+		t.Errorf("Invalid = %v, want %v", check.Invalid, want)
+	}
+}
+
+func TestSourceLabel(t *testing.T) {
+	p := &Passage{Path: "data/ent.md", Heading: "Edges", StartLine: 12, EndLine: 40}
+	if got, want := sourceLabel(p), "data/ent.md § Edges (L12-40)"; got != want {
+		t.Errorf("sourceLabel = %q, want %q", got, want)
+	}
+	// 旧数据没有标题和行号
+	if got := sourceLabel(&Passage{Path: "data/ent.md"}); got != "data/ent.md" {
+		t.Errorf("sourceLabel = %q", got)
+	}
+}
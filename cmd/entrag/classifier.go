@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 查询分类方式
+const (
+	classifyRules     = "rules"     // 按触发词匹配
+	classifyEmbedding = "embedding" // 与示例问题的向量最近邻
+)
+
+// defaultQueryTypes 内置的查询类型，未在配置中定义 classifier.query_types 时使用
+func defaultQueryTypes() []QueryTypeConfig {
+	return []QueryTypeConfig{
+		{
+			Name:       "概念性",
+			Prompt:     "concept",
+			Patterns:   []string{"what is", "什么是", "定义", "概念"},
+			Keywords:   []string{"定义", "是", "describes", "definition", "ent", "orm"},
+			MaxPerFile: 3, // 概念性问题需要更多样化的来源
+			Exemplars:  []string{"什么是Ent ORM？", "What is a schema edge?"},
+		},
+		{
+			Name:       "操作性",
+			Prompt:     "howto",
+			Patterns:   []string{"how to", "如何", "怎样", "方法"},
+			Keywords:   []string{"步骤", "方法", "how", "step", "func", "function"},
+			MaxPerFile: 5, // 操作性问题可能需要更多细节
+			Exemplars:  []string{"如何定义关系？", "How to create a migration?"},
+		},
+		{
+			Name:       "比较性",
+			Prompt:     "compare",
+			Patterns:   []string{"difference", "区别", "比较", "对比"},
+			Keywords:   []string{"vs", "compared", "difference", "pdm", "plm"},
+			MaxPerFile: 4,
+			Exemplars:  []string{"PDM和PLM的区别？", "What is the difference between hooks and interceptors?"},
+		},
+		{
+			Name:       "列举性",
+			Prompt:     "list",
+			Patterns:   []string{"列举", "有哪些", "特点", "优点"},
+			Keywords:   []string{"特点", "优点", "advantages", "features", "ent", "orm"},
+			MaxPerFile: 4,
+			Exemplars:  []string{"列举Ent ORM的优点", "List the features of ent"},
+		},
+		{
+			Name:       "通用",
+			Prompt:     "general",
+			MaxPerFile: 4,
+		},
+	}
+}
+
+// QueryClassifier 根据配置识别问题的查询类型
+type QueryClassifier struct {
+	cfg   ClassifierConfig
+	embed func(string) ([]float32, error)
+
+	exemplars map[string][][]float32 // 查询类型 -> 示例问题的向量
+}
+
+// newQueryClassifier 创建查询分类器，embed用于embedding模式下计算示例问题的向量
+func newQueryClassifier(cfg ClassifierConfig, embed func(string) ([]float32, error)) *QueryClassifier {
+	return &QueryClassifier{cfg: cfg, embed: embed}
+}
+
+// Classify 返回问题的查询类型。embedding模式下使用与问题向量最相似的示例问题
+// 所属的类型，相似度低于阈值或计算失败时退回规则匹配。
+func (c *QueryClassifier) Classify(question string, emb []float32) QueryTypeConfig {
+	if c.cfg.Mode == classifyEmbedding && emb != nil {
+		qt, err := c.classifyByEmbedding(emb)
+		if err != nil {
+			log.Printf("Warning: embedding classifier failed, falling back to rules: %v", err)
+		} else if qt != nil {
+			return *qt
+		}
+	}
+	return c.classifyByRules(question)
+}
+
+// classifyByRules 按配置顺序返回第一个匹配触发词的查询类型
+func (c *QueryClassifier) classifyByRules(question string) QueryTypeConfig {
+	question = strings.ToLower(question)
+	for _, qt := range c.cfg.QueryTypes {
+		for _, p := range qt.Patterns {
+			if strings.Contains(question, strings.ToLower(p)) {
+				return qt
+			}
+		}
+	}
+	return c.defaultType()
+}
+
+// classifyByEmbedding 最近邻分类，没有足够相似的示例时返回nil
+func (c *QueryClassifier) classifyByEmbedding(emb []float32) (*QueryTypeConfig, error) {
+	if c.exemplars == nil {
+		exemplars := make(map[string][][]float32)
+		for _, qt := range c.cfg.QueryTypes {
+			for _, q := range qt.Exemplars {
+				e, err := c.embed(q)
+				if err != nil {
+					return nil, fmt.Errorf("embedding exemplar %q: %w", q, err)
+				}
+				exemplars[qt.Name] = append(exemplars[qt.Name], e)
+			}
+		}
+		c.exemplars = exemplars
+	}
+
+	var best *QueryTypeConfig
+	bestSim := 0.0
+	for i, qt := range c.cfg.QueryTypes {
+		for _, e := range c.exemplars[qt.Name] {
+			sim := cosineSimilarity(emb, e)
+			if sim >= c.cfg.MinSimilarity && (best == nil || sim > bestSim) {
+				best, bestSim = &c.cfg.QueryTypes[i], sim
+			}
+		}
+	}
+	return best, nil
+}
+
+// defaultType 返回默认查询类型
+func (c *QueryClassifier) defaultType() QueryTypeConfig {
+	for _, qt := range c.cfg.QueryTypes {
+		if qt.Name == c.cfg.DefaultType {
+			return qt
+		}
+	}
+	return QueryTypeConfig{Name: c.cfg.DefaultType}
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestClassifyByRules(t *testing.T) {
+	cfg := &Config{}
+	cfg.applyDefaults()
+	c := newQueryClassifier(cfg.Classifier, nil)
+
+	tests := map[string]string{
+		"什么是Ent ORM？":                 "概念性",
+		"How to define schema edges?": "操作性",
+		"PDM和PLM的区别？":                 "比较性",
+		"列举Ent ORM的优点":                "列举性",
+		"hello":                       "通用",
+	}
+	for question, want := range tests {
+		if got := c.Classify(question, nil).Name; got != want {
+			t.Errorf("Classify(%q) = %q, want %q", question, got, want)
+		}
+	}
+}
+
+func TestClassifyByEmbedding(t *testing.T) {
+	vectors := map[string][]float32{
+		"concept": {1, 0},
+		"howto":   {0, 1},
+	}
+	cfg := ClassifierConfig{
+		Mode:          "embedding",
+		MinSimilarity: 0.8,
+		DefaultType:   "通用",
+		QueryTypes: []QueryTypeConfig{
+			{Name: "概念性", Patterns: []string{"什么是"}, Exemplars: []string{"concept"}},
+			{Name: "操作性", Exemplars: []string{"howto"}, MaxPerFile: 5},
+		},
+	}
+	c := newQueryClassifier(cfg, func(text string) ([]float32, error) {
+		return vectors[text], nil
+	})
+
+	if got := c.Classify("anything", []float32{0.1, 0.9}); got.Name != "操作性" || got.MaxPerFile != 5 {
+		t.Errorf("nearest exemplar: got %+v", got)
+	}
+	// 相似度不足时退回规则匹配
+	if got := c.Classify("什么是hook", []float32{0.7, 0.7}).Name; got != "概念性" {
+		t.Errorf("rules fallback: got %q", got)
+	}
+	if got := c.Classify("hello", []float32{0.7, 0.7}).Name; got != "通用" {
+		t.Errorf("default type: got %q", got)
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// goFence 匹配go代码块的开始行，如 ```go 或 ```golang
+var goFence = regexp.MustCompile("^\\s*```\\s*(?:go|golang)\\s*$")
+
+// qualifiedIdent 匹配文档中的限定标识符，如 entsql.IndexType
+var qualifiedIdent = regexp.MustCompile(`(?:^|[^\w.])([A-Za-z_]\w*)\.([A-Za-z_]\w*)`)
+
+// maxSyntaxErrors 每个代码块最多报告的语法错误数
+const maxSyntaxErrors = 3
+
+// maxIdentDistance 标识符与文档中符号的编辑距离上限：5个字符以内的名字只允许差1个字符，
+// 更长的名字每5个字符允许差1个，最多为该值
+const maxIdentDistance = 2
+
+// stdPackages 标准库中常用的包名，回答中调用这些包时不与文档核对：
+// 文档中的 fmt.Printf 不能说明 fmt.Println 拼错了
+var stdPackages = map[string]bool{
+	"bufio": true, "bytes": true, "context": true, "errors": true, "filepath": true,
+	"flag": true, "fmt": true, "http": true, "io": true, "json": true, "log": true,
+	"maps": true, "math": true, "os": true, "path": true, "reflect": true,
+	"regexp": true, "slices": true, "slog": true, "sort": true, "strconv": true,
+	"strings": true, "sync": true, "testing": true, "time": true, "unicode": true,
+	"url": true, "utf8": true,
+}
+
+// codeIssue 代码块中的一个问题
+type codeIssue struct {
+	Line    int    `json:"line"` // 代码块内的行号，从1开始
+	Message string `json:"message"`
+	// Suggestion 为文档中最接近的符号，只有标识符问题才有
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// codeCheck 回答中一个go代码块的检查结果
+type codeCheck struct {
+	Block        int         `json:"block"` // 回答中第几个go代码块，从1开始
+	Line         int         `json:"line"`  // 代码块第一行在回答中的行号
+	Valid        bool        `json:"valid"`
+	SyntaxErrors []codeIssue `json:"syntax_errors,omitempty"`
+	UnknownIdent []codeIssue `json:"unknown_identifiers,omitempty"`
+}
+
+// goBlock 回答中的go代码块
+type goBlock struct {
+	Code string
+	Line int
+}
+
+// extractGoBlocks 提取回答中的go代码块，未闭合的代码块截止到回答末尾
+func extractGoBlocks(answer string) []goBlock {
+	var blocks []goBlock
+	var current *goBlock
+	var lines []string
+	for i, line := range strings.Split(answer, "\n") {
+		switch {
+		case current == nil && goFence.MatchString(line):
+			current = &goBlock{Line: i + 2}
+			lines = nil
+		case current != nil && strings.HasPrefix(strings.TrimSpace(line), "```"):
+			current.Code = strings.Join(lines, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+		case current != nil:
+			lines = append(lines, line)
+		}
+	}
+	if current != nil {
+		current.Code = strings.Join(lines, "\n")
+		blocks = append(blocks, *current)
+	}
+	return blocks
+}
+
+// snippetWrapper 将代码片段补全为完整的go文件
+type snippetWrapper struct {
+	prefix string
+	suffix string
+}
+
+var (
+	wrapFile  = snippetWrapper{}                                                 // 完整的文件
+	wrapDecls = snippetWrapper{prefix: "package p\n"}                            // 顶层声明
+	wrapStmts = snippetWrapper{prefix: "package p\nfunc _() {\n", suffix: "\n}"} // 函数体中的语句
+)
+
+// wrappersFor 按代码片段的开头猜测其形式，返回依次尝试的补全方式
+func wrappersFor(code string) []snippetWrapper {
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "package ") {
+			return []snippetWrapper{wrapFile}
+		}
+		for _, kw := range []string{"import", "func", "type", "var", "const"} {
+			if strings.HasPrefix(line, kw+" ") || strings.HasPrefix(line, kw+"(") {
+				return []snippetWrapper{wrapDecls, wrapStmts}
+			}
+		}
+		break
+	}
+	return []snippetWrapper{wrapStmts, wrapDecls}
+}
+
+// checkSyntax 用go/parser和go/format检查代码片段，片段可以是完整文件、顶层声明或语句。
+// 所有补全方式都失败时，报告第一种方式的错误。
+func checkSyntax(code string) []codeIssue {
+	var first []codeIssue
+	for i, w := range wrappersFor(code) {
+		src := w.prefix + code + w.suffix
+		offset := strings.Count(w.prefix, "\n")
+		_, err := parser.ParseFile(token.NewFileSet(), "", src, parser.AllErrors)
+		if err == nil {
+			// go/format在解析之外还会检查代码能否被格式化输出
+			_, err = format.Source([]byte(src))
+		}
+		if err == nil {
+			return nil
+		}
+		if i == 0 {
+			first = syntaxIssues(err, offset, strings.Count(code, "\n")+1)
+		}
+	}
+	return first
+}
+
+// syntaxIssues 将解析错误转换为代码块内的行号，补全部分中的错误算作代码块首行或末行
+func syntaxIssues(err error, offset, lines int) []codeIssue {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return []codeIssue{{Message: err.Error()}}
+	}
+	var issues []codeIssue
+	for _, e := range list {
+		if len(issues) == maxSyntaxErrors {
+			break
+		}
+		line := min(max(e.Pos.Line-offset, 1), lines)
+		issues = append(issues, codeIssue{Line: line, Message: e.Msg})
+	}
+	return issues
+}
+
+// passageSymbols 收集段落中出现的限定标识符，按限定符分组
+func passageSymbols(passages []*Passage) map[string]map[string]bool {
+	symbols := make(map[string]map[string]bool)
+	for _, p := range passages {
+		for _, m := range qualifiedIdent.FindAllStringSubmatch(p.Text, -1) {
+			if symbols[m[1]] == nil {
+				symbols[m[1]] = make(map[string]bool)
+			}
+			symbols[m[1]][m[2]] = true
+		}
+	}
+	return symbols
+}
+
+// checkIdentifiers 将代码中的 x.Name 与段落中的符号核对：限定符在文档中出现、
+// 但Name不在其中且与文档中的某个符号只差几个字符时，视为拼写错误。
+// go/scanner不要求代码能解析，语法错误的代码块同样会检查。
+func checkIdentifiers(code string, symbols map[string]map[string]bool) []codeIssue {
+	var issues []codeIssue
+	reported := make(map[string]bool)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+	var s scanner.Scanner
+	s.Init(file, []byte(code), nil, scanner.ScanComments)
+
+	// 依次记录最近的三个token，识别 IDENT . IDENT 且前面不是 "."
+	var prev, prev2 token.Token
+	var qualifier, prevLit string
+	qualified := false // 上一个 "." 前是否为一个独立的标识符
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.COMMENT {
+			continue
+		}
+		if tok == token.IDENT && prev == token.PERIOD && qualified {
+			name := qualifier + "." + lit
+			if members := symbols[qualifier]; members != nil && !stdPackages[qualifier] && !members[lit] && !reported[name] {
+				if suggestion, ok := closestSymbol(lit, members); ok {
+					reported[name] = true
+					issues = append(issues, codeIssue{
+						Line:       fset.Position(pos).Line,
+						Message:    fmt.Sprintf("文档中没有 %s", name),
+						Suggestion: qualifier + "." + suggestion,
+					})
+				}
+			}
+		}
+		if tok == token.PERIOD {
+			qualified = prev == token.IDENT && prev2 != token.PERIOD
+			qualifier = prevLit
+		}
+		prev2, prev, prevLit = prev, tok, lit
+	}
+	return issues
+}
+
+// identDistance 返回name允许的最大编辑距离，随名字长度增加
+func identDistance(name string) int {
+	return min(max(1, len(name)/5), maxIdentDistance)
+}
+
+// closestSymbol 返回members中与name编辑距离最小且在identDistance以内的符号。
+// 在单词边界处互为前缀或后缀的名字（如 field.Int 与 field.Ints）通常是不同的符号，不视为拼写错误。
+func closestSymbol(name string, members map[string]bool) (string, bool) {
+	candidates := make([]string, 0, len(members))
+	for m := range members {
+		candidates = append(candidates, m)
+	}
+	sort.Strings(candidates)
+	best, bestDistance := "", identDistance(name)+1
+	for _, m := range candidates {
+		if extendsSymbol(name, m) {
+			continue
+		}
+		if d := editDistance(name, m); d < bestDistance {
+			best, bestDistance = m, d
+		}
+	}
+	return best, best != ""
+}
+
+// extendsSymbol 判断a和b是否一个是另一个在单词边界处的扩展，如 Int 与 Ints、UUID 与 NewUUID；
+// IndexTyp 与 IndexType 不在单词边界处，仍可能是拼写错误
+func extendsSymbol(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	switch {
+	case a == "":
+		return false
+	case strings.HasPrefix(b, a):
+		rest := b[len(a):]
+		return rest == "s" || rest == "es" || startsWord(rest)
+	case strings.HasSuffix(b, a):
+		return startsWord(a)
+	}
+	return false
+}
+
+// startsWord 判断s是否以驼峰命名中一个新单词的开头开始
+func startsWord(s string) bool {
+	r := rune(s[0])
+	return unicode.IsUpper(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// editDistance 计算两个字符串的Levenshtein距离
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			diag, row[j] = row[j], min(row[j]+1, row[j-1]+1, diag+cost)
+		}
+	}
+	return row[len(rb)]
+}
+
+// checkCode 检查回答中的所有go代码块
+func checkCode(answer string, passages []*Passage) []codeCheck {
+	blocks := extractGoBlocks(answer)
+	if len(blocks) == 0 {
+		return nil
+	}
+	symbols := passageSymbols(passages)
+	checks := make([]codeCheck, len(blocks))
+	for i, b := range blocks {
+		checks[i] = codeCheck{
+			Block:        i + 1,
+			Line:         b.Line,
+			SyntaxErrors: checkSyntax(b.Code),
+			UnknownIdent: checkIdentifiers(b.Code, symbols),
+		}
+		checks[i].Valid = len(checks[i].SyntaxErrors) == 0 && len(checks[i].UnknownIdent) == 0
+	}
+	return checks
+}
+
+// annotateCode 在回答的go代码块中出现语法错误的行之后插入注释，使错误显示在代码旁
+func annotateCode(answer string, checks []codeCheck) string {
+	notes := make(map[int][]string) // 回答中的行号 -> 错误信息
+	for _, c := range checks {
+		for _, issue := range c.SyntaxErrors {
+			line := c.Line + max(issue.Line, 1) - 1
+			notes[line] = append(notes[line], issue.Message)
+		}
+	}
+	if len(notes) == 0 {
+		return answer
+	}
+	var b strings.Builder
+	for i, line := range strings.Split(answer, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		for _, msg := range notes[i+1] {
+			fmt.Fprintf(&b, "\n%s// ❌ 语法错误: %s", indent, msg)
+		}
+	}
+	return b.String()
+}
+
+// annotatedBlocks 返回标注后的回答中有语法错误的代码块，用于无法重新渲染回答时单独输出
+func annotatedBlocks(annotated string, checks []codeCheck) string {
+	blocks := extractGoBlocks(annotated)
+	var b strings.Builder
+	for _, c := range checks {
+		if len(c.SyntaxErrors) == 0 || c.Block > len(blocks) {
+			continue
+		}
+		fmt.Fprintf(&b, "\n🧪 代码块%d 的语法错误:\n```go\n%s\n```\n", c.Block, blocks[c.Block-1].Code)
+	}
+	return b.String()
+}
+
+// printCodeChecks 输出代码检查结果，全部通过时只输出一行
+func printCodeChecks(w io.Writer, checks []codeCheck) {
+	if len(checks) == 0 {
+		return
+	}
+	valid := 0
+	for _, c := range checks {
+		if c.Valid {
+			valid++
+		}
+	}
+	fmt.Fprintf(w, "🧪 代码检查: %d/%d 个go代码块通过\n", valid, len(checks))
+	for _, c := range checks {
+		for _, issue := range c.SyntaxErrors {
+			fmt.Fprintf(w, "   ❌ 代码块%d 第%d行 (回答第%d行): %s\n", c.Block, issue.Line, c.Line+issue.Line-1, issue.Message)
+		}
+		for _, issue := range c.UnknownIdent {
+			fmt.Fprintf(w, "   ⚠️  代码块%d 第%d行 (回答第%d行): %s，是否应为 %s？\n", c.Block, issue.Line, c.Line+issue.Line-1, issue.Message, issue.Suggestion)
+		}
+	}
+}
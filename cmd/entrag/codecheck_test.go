@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExtractGoBlocks(t *testing.T) {
+	answer := "Use a schema:\n```go\nfunc (User) Fields() []ent.Field {\n\treturn nil\n}\n```\n```sql\nSELECT 1;\n```\n```golang\nx := 1\n"
+	blocks := extractGoBlocks(answer)
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 go blocks, got %+v", blocks)
+	}
+	if blocks[0].Line != 3 || !strings.HasPrefix(blocks[0].Code, "func (User)") {
+		t.Errorf("unexpected first block %+v", blocks[0])
+	}
+	// 未闭合的代码块截止到回答末尾
+	if blocks[1].Line != 11 || strings.TrimSpace(blocks[1].Code) != "x := 1" {
+		t.Errorf("unexpected second block %+v", blocks[1])
+	}
+}
+
+func TestCheckSyntax(t *testing.T) {
+	valid := []string{
+		"package schema\n\nimport \"entgo.io/ent\"\n\ntype User struct{ ent.Schema }",
+		"func (User) Fields() []ent.Field {\n\treturn []ent.Field{field.String(\"name\")}\n}",
+		"client, err := ent.Open(\"postgres\", dsn)\nif err != nil {\n\tlog.Fatal(err)\n}",
+	}
+	for _, code := range valid {
+		if issues := checkSyntax(code); len(issues) != 0 {
+			t.Errorf("checkSyntax(%q) = %+v, want no issues", code, issues)
+		}
+	}
+	issues := checkSyntax("u, err := client.User.\n\tCreate().\n\tSetName(\"a\"\n\tSave(ctx)")
+	if len(issues) == 0 || issues[0].Line != 3 {
+		t.Errorf("expected a syntax error on line 3, got %+v", issues)
+	}
+	for _, issue := range issues {
+		if issue.Line > 4 {
+			t.Errorf("issue outside of the block: %+v", issue)
+		}
+	}
+}
+
+func TestCheckCode(t *testing.T) {
+	passages := []*Passage{{Text: "Use `entsql.IndexType(\"GIN\")` via index.Fields(\"name\").Annotations(entsql.IndexType(\"GIN\"))."}}
+	answer := "```go\nindex.Fields(\"name\").\n\tAnnotations(entsql.IndexTyp(\"GIN\"), entsql.Prefix(10))\n```"
+	checks := checkCode(answer, passages)
+	if len(checks) != 1 || checks[0].Valid || len(checks[0].SyntaxErrors) != 0 {
+		t.Fatalf("unexpected checks %+v", checks)
+	}
+	// entsql.Prefix与文档中的符号相差太远，不视为拼写错误；链式调用中的 .Annotations 不以index为限定符
+	issues := checks[0].UnknownIdent
+	if len(issues) != 1 || issues[0].Line != 2 || issues[0].Suggestion != "entsql.IndexType" {
+		t.Errorf("unexpected identifier issues %+v", issues)
+	}
+
+	var out bytes.Buffer
+	printCodeChecks(&out, checks)
+	if !strings.Contains(out.String(), "0/1") || !strings.Contains(out.String(), "是否应为 entsql.IndexType") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestCheckIdentifiersSimilarNames(t *testing.T) {
+	passages := []*Passage{{Text: "fmt.Printf(\"%v\", v) and field.Int(\"age\"), field.String(\"name\"), schema.Mixin"}}
+	symbols := passageSymbols(passages)
+	// 标准库、互为扩展的名字和足够长的不同名字都不是拼写错误
+	code := "fmt.Println(v)\nfield.Ints(\"scores\")\nfield.Strings(\"tags\")\nschema.Mixins()"
+	if issues := checkIdentifiers(code, symbols); len(issues) != 0 {
+		t.Errorf("unexpected identifier issues %+v", issues)
+	}
+	// 短名字只允许差一个字符
+	if issues := checkIdentifiers("field.Itn(\"age\")\nfield.Strng(\"name\")", symbols); len(issues) != 1 || issues[0].Suggestion != "field.String" {
+		t.Errorf("unexpected identifier issues %+v", issues)
+	}
+}
+
+func TestAnnotateCode(t *testing.T) {
+	answer := "示例:\n\n```go\nfunc main() {\n\tfmt.Println(\"x\"\n}\n```"
+	checks := checkCode(answer, nil)
+	annotated := annotateCode(answer, checks)
+	if len(checks) != 1 || !strings.Contains(annotated, "\tfmt.Println(\"x\"\n\t// ❌ 语法错误: ") {
+		t.Fatalf("unexpected annotation:\n%s", annotated)
+	}
+	if blocks := annotatedBlocks(annotated, checks); !strings.Contains(blocks, "代码块1 的语法错误") || !strings.Contains(blocks, "// ❌ 语法错误") {
+		t.Errorf("unexpected blocks:\n%s", blocks)
+	}
+	if valid := "```go\nx := 1\n```"; annotateCode(valid, checkCode(valid, nil)) != valid {
+		t.Errorf("valid code was annotated")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{{"IndexTyp", "IndexType", 1}, {"", "abc", 3}, {"kitten", "sitting", 3}, {"同样", "同样", 0}} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
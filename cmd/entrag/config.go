@@ -4,16 +4,29 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Database DatabaseConfig `yaml:"database"`
-	Ollama   OllamaConfig   `yaml:"ollama"`
-	App      AppConfig      `yaml:"app"`
-	Logging  LoggingConfig  `yaml:"logging"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Ollama     OllamaConfig     `yaml:"ollama"`
+	Generator  GeneratorConfig  `yaml:"generator"`
+	App        AppConfig        `yaml:"app"`
+	Retrieval  RetrievalConfig  `yaml:"retrieval"`
+	Classifier ClassifierConfig `yaml:"classifier"`
+	Rerank     RerankConfig     `yaml:"rerank"`
+	Prompts    PromptsConfig    `yaml:"prompts"`
+	Grounding  GroundingConfig  `yaml:"grounding"`
+	Extractive ExtractiveConfig `yaml:"extractive"`
+	Agent      AgentConfig      `yaml:"agent"`
+	// Collections names groups of documents by path, e.g. "ent": data/ent/.
+	Collections map[string]CollectionConfig `yaml:"collections"`
+	Logging     LoggingConfig               `yaml:"logging"`
 }
 
 // DatabaseConfig represents database configuration
@@ -32,6 +45,140 @@ type OllamaConfig struct {
 	URL        string `yaml:"url"`
 	EmbedModel string `yaml:"embed_model"`
 	ChatModel  string `yaml:"chat_model"`
+	// NumCtx and AnswerTokens are the defaults for chat models not listed in Models.
+	NumCtx       int                    `yaml:"num_ctx"`
+	AnswerTokens int                    `yaml:"answer_tokens"`
+	Models       map[string]ModelConfig `yaml:"models"`
+	// Options are sent with every answer generation request.
+	Options GenerationOptions `yaml:"options"`
+}
+
+// GenerationOptions are the Ollama request options used to generate answers.
+// Unset (nil) values leave the model's defaults in place. The struct doubles as
+// the per-invocation override flags of ask and chat.
+type GenerationOptions struct {
+	Temperature *float64 `yaml:"temperature" help:"Sampling temperature (0 for deterministic answers)."`
+	Seed        *int     `yaml:"seed" help:"Random seed for reproducible answers."`
+	TopP        *float64 `yaml:"top_p" name:"top-p" help:"Nucleus sampling probability."`
+	// NumCtx overrides the context window of the chat model, which also
+	// enlarges the token budget of the prompt.
+	NumCtx int      `yaml:"num_ctx" name:"num-ctx" help:"Context window in tokens (overrides ollama.num_ctx)."`
+	Stop   []string `yaml:"stop" sep:"none" help:"Stop sequence; may be repeated."`
+}
+
+// Merge returns o with the values set in override taking precedence.
+func (o GenerationOptions) Merge(override GenerationOptions) GenerationOptions {
+	if override.Temperature != nil {
+		o.Temperature = override.Temperature
+	}
+	if override.Seed != nil {
+		o.Seed = override.Seed
+	}
+	if override.TopP != nil {
+		o.TopP = override.TopP
+	}
+	if override.NumCtx != 0 {
+		o.NumCtx = override.NumCtx
+	}
+	if len(override.Stop) > 0 {
+		o.Stop = override.Stop
+	}
+	return o
+}
+
+// Map returns the options in the form of Ollama's "options" request field.
+func (o GenerationOptions) Map() map[string]any {
+	m := make(map[string]any)
+	if o.Temperature != nil {
+		m["temperature"] = *o.Temperature
+	}
+	if o.Seed != nil {
+		m["seed"] = *o.Seed
+	}
+	if o.TopP != nil {
+		m["top_p"] = *o.TopP
+	}
+	if o.NumCtx != 0 {
+		m["num_ctx"] = o.NumCtx
+	}
+	if len(o.Stop) > 0 {
+		m["stop"] = o.Stop
+	}
+	return m
+}
+
+// ModelConfig represents per chat model configuration
+type ModelConfig struct {
+	// NumCtx is the context window (in tokens) requested from Ollama.
+	NumCtx int `yaml:"num_ctx"`
+	// AnswerTokens is the part of the context window reserved for the answer.
+	AnswerTokens int `yaml:"answer_tokens"`
+}
+
+// ChatModelConfig returns the configuration of the given chat model, falling
+// back to the defaults for unset values. options.num_ctx takes precedence.
+func (c OllamaConfig) ChatModelConfig(model string) ModelConfig {
+	mc := c.Models[model]
+	if c.Options.NumCtx != 0 {
+		mc.NumCtx = c.Options.NumCtx
+	}
+	if mc.NumCtx == 0 {
+		mc.NumCtx = c.NumCtx
+	}
+	if mc.AnswerTokens == 0 {
+		mc.AnswerTokens = c.AnswerTokens
+	}
+	return mc
+}
+
+// GroundingConfig represents the fact check of generated answers
+type GroundingConfig struct {
+	// Enabled checks every answer; ask --verify enables it for one question.
+	Enabled bool `yaml:"enabled"`
+	// Method is "lexical" (token overlap with a passage) or "llm" (the chat model judges each sentence).
+	Method string `yaml:"method"`
+	// Threshold is the share of a sentence's tokens that must occur in one
+	// passage for the sentence to count as supported ("lexical").
+	Threshold float64 `yaml:"threshold"`
+}
+
+// ExtractiveConfig represents extractive answers, which quote the best
+// matching sentences of the passages instead of calling the chat model
+type ExtractiveConfig struct {
+	// Fallback answers extractively when generation fails or times out
+	// (default true).
+	Fallback *bool `yaml:"fallback"`
+	// Timeout in seconds for answer generation; 0 waits indefinitely.
+	Timeout int `yaml:"timeout"`
+	// Sentences is the number of sentences in an extractive answer.
+	Sentences int `yaml:"sentences"`
+	// LexicalWeight is the weight of the token overlap with the question; the
+	// embedding similarity gets the rest.
+	LexicalWeight *float64 `yaml:"lexical_weight"`
+}
+
+// AgentConfig represents the multi-step retrieval of ask --agent
+type AgentConfig struct {
+	// SubQuestions is the maximum number of sub-questions searched separately.
+	SubQuestions int `yaml:"sub_questions"`
+	// MaxSteps is the maximum number of follow-up searches the model may request
+	// through tool calling; 0 disables them.
+	MaxSteps int `yaml:"max_steps"`
+	// Model defaults to ollama.chat_model and must support tool calling.
+	Model string `yaml:"model"`
+}
+
+// GeneratorConfig selects the backend that generates answers
+type GeneratorConfig struct {
+	// Type is "ollama" or "openai" (a local OpenAI-compatible server).
+	Type string `yaml:"type"`
+	// URL is the base URL of the OpenAI-compatible server, including /v1.
+	URL string `yaml:"url"`
+	// Model defaults to ollama.chat_model. Its context window is looked up in
+	// ollama.models like any chat model.
+	Model string `yaml:"model"`
+	// APIKey is sent as a bearer token when set.
+	APIKey string `yaml:"api_key"`
 }
 
 // AppConfig represents application configuration
@@ -44,6 +191,111 @@ type AppConfig struct {
 	MinChunkSize        int    `yaml:"min_chunk_size"`
 }
 
+// RetrievalConfig represents retrieval configuration
+type RetrievalConfig struct {
+	// Hybrid enables full-text search alongside vector search.
+	Hybrid bool `yaml:"hybrid"`
+	// VectorWeight and TextWeight weight each ranked list in reciprocal rank
+	// fusion (default 1.0); 0 turns that side of the fusion off.
+	VectorWeight *float64 `yaml:"vector_weight"`
+	TextWeight   *float64 `yaml:"text_weight"`
+	// RRFK is the rank constant k of reciprocal rank fusion.
+	RRFK int `yaml:"rrf_k"`
+	// Diversity selects the diversification strategy: "round_robin" or "mmr".
+	Diversity string `yaml:"diversity"`
+	// MMRLambda trades relevance (1.0) against diversity (0.0) for "mmr".
+	MMRLambda *float64 `yaml:"mmr_lambda"`
+	// MaxDistance drops chunks whose L2 distance to the question exceeds it
+	// (0 disables). Chunks found only by full-text search, which have no
+	// vector yet, are never dropped.
+	MaxDistance *float64 `yaml:"max_distance"`
+	// NeighborWindow expands each selected chunk with this many preceding and
+	// following chunks of the same file (0 disables).
+	NeighborWindow int `yaml:"neighbor_window"`
+	// Mode selects how the question is searched: "single", "multi_query"
+	// (paraphrases generated by the chat model) or "hyde" (a hypothetical answer).
+	Mode string `yaml:"mode"`
+	// Paraphrases is the number of paraphrases generated in "multi_query" mode.
+	Paraphrases int `yaml:"paraphrases"`
+	// CrossLingual also searches translations of the question.
+	CrossLingual CrossLingualConfig `yaml:"cross_lingual"`
+}
+
+// CrossLingualConfig represents cross-lingual retrieval configuration
+type CrossLingualConfig struct {
+	// Enabled searches the question translated into the other corpus languages.
+	Enabled bool `yaml:"enabled"`
+	// Method is "llm" (translated by the chat model) or "glossary" (term substitution).
+	Method string `yaml:"method"`
+	// Languages are the languages of the corpus, e.g. [zh, en].
+	Languages []string `yaml:"languages"`
+	// Glossary lists equivalent terms keyed by language, e.g. {zh: 边, en: edge}.
+	// It is also used when the llm translation fails.
+	Glossary []map[string]string `yaml:"glossary"`
+}
+
+// ClassifierConfig represents query classification configuration
+type ClassifierConfig struct {
+	// Mode is "rules" (trigger patterns) or "embedding" (nearest exemplar question).
+	Mode string `yaml:"mode"`
+	// MinSimilarity is the cosine similarity an exemplar must reach in "embedding"
+	// mode; below it the rules are used.
+	MinSimilarity float64 `yaml:"min_similarity"`
+	// DefaultType is the query type used when nothing matches.
+	DefaultType string            `yaml:"default_type"`
+	QueryTypes  []QueryTypeConfig `yaml:"query_types"`
+}
+
+// QueryTypeConfig represents a query type and how retrieval treats it
+type QueryTypeConfig struct {
+	Name string `yaml:"name"`
+	// Patterns are case-insensitive substrings of the question that trigger the type.
+	Patterns []string `yaml:"patterns"`
+	// Keywords admit a chunk in filtering when it contains any of them.
+	Keywords []string `yaml:"keywords"`
+	// MaxPerFile caps the chunks selected from a single file.
+	MaxPerFile int `yaml:"max_per_file"`
+	// K is the number of chunks to select (0 uses app.max_similar_chunks).
+	K int `yaml:"k"`
+	// Exemplars are labelled example questions for the "embedding" mode.
+	Exemplars []string `yaml:"exemplars"`
+	// Prompt is the name of the prompt template (defaults to the type name).
+	Prompt string `yaml:"prompt"`
+}
+
+// PromptsConfig represents prompt template configuration
+type PromptsConfig struct {
+	// Dir holds template overrides: <dir>/<name>.tmpl, and
+	// <dir>/<collection>/<name>.tmpl for a collection.
+	Dir string `yaml:"dir"`
+}
+
+// CollectionConfig represents a named collection of documents
+type CollectionConfig struct {
+	// Path is a path prefix or glob, as in the --path filter.
+	Path string `yaml:"path"`
+}
+
+// RerankConfig represents reranking configuration
+type RerankConfig struct {
+	// Type selects the reranker: "none", "lexical", "llm" or "cross_encoder".
+	Type         string         `yaml:"type"`
+	Lexical      RerankerConfig `yaml:"lexical"`
+	LLM          RerankerConfig `yaml:"llm"`
+	CrossEncoder RerankerConfig `yaml:"cross_encoder"`
+}
+
+// RerankerConfig represents the configuration of a single reranker
+type RerankerConfig struct {
+	// TopN is the number of best fused candidates that are reranked; the rest are dropped.
+	TopN int `yaml:"top_n"`
+	// Model is the chat model scoring relevance ("llm", defaults to generator.model
+	// and runs on the generator backend) or the cross-encoder model ("cross_encoder").
+	Model string `yaml:"model"`
+	// URL is the OpenAI-compatible rerank endpoint of the cross-encoder.
+	URL string `yaml:"url"`
+}
+
 // LoggingConfig represents logging configuration
 type LoggingConfig struct {
 	Level  string `yaml:"level"`
@@ -93,9 +345,188 @@ func LoadConfig(configPath string) (*Config, error) {
 		config.Ollama.ChatModel = chatModel
 	}
 
+	config.applyDefaults()
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+
 	return &config, nil
 }
 
+// validate rejects settings that would otherwise be silently ignored
+func (c *Config) validate() error {
+	for _, e := range []struct {
+		name, value string
+		allowed     []string
+	}{
+		{"generator.type", c.Generator.Type, []string{generatorOllama, generatorOpenAI}},
+		{"retrieval.diversity", c.Retrieval.Diversity, []string{"round_robin", "mmr"}},
+		{"retrieval.mode", c.Retrieval.Mode, []string{modeSingle, modeMultiQuery, modeHyDE}},
+		{"retrieval.cross_lingual.method", c.Retrieval.CrossLingual.Method, []string{translateLLM, translateGlossary}},
+		{"classifier.mode", c.Classifier.Mode, []string{classifyRules, classifyEmbedding}},
+		{"rerank.type", c.Rerank.Type, []string{"none", "lexical", "llm", "cross_encoder"}},
+		{"grounding.method", c.Grounding.Method, []string{groundingLexical, groundingLLM}},
+	} {
+		if !slices.Contains(e.allowed, e.value) {
+			return fmt.Errorf("unknown %s %q (want %s)", e.name, e.value, strings.Join(e.allowed, ", "))
+		}
+	}
+	if !slices.ContainsFunc(c.Classifier.QueryTypes, func(qt QueryTypeConfig) bool { return qt.Name == c.Classifier.DefaultType }) {
+		return fmt.Errorf("classifier.default_type %q is not one of classifier.query_types", c.Classifier.DefaultType)
+	}
+	if c.Rerank.Type == "cross_encoder" && c.Rerank.CrossEncoder.Model == "" {
+		return fmt.Errorf("rerank.cross_encoder.model is required")
+	}
+	if w := *c.Retrieval.VectorWeight; w < 0 {
+		return fmt.Errorf("retrieval.vector_weight %v is negative", w)
+	}
+	if w := *c.Retrieval.TextWeight; w < 0 {
+		return fmt.Errorf("retrieval.text_weight %v is negative", w)
+	}
+	if *c.Retrieval.VectorWeight == 0 && *c.Retrieval.TextWeight == 0 {
+		return fmt.Errorf("retrieval.vector_weight and retrieval.text_weight are both 0")
+	}
+	if c.Retrieval.RRFK < 0 {
+		return fmt.Errorf("retrieval.rrf_k %d is negative", c.Retrieval.RRFK)
+	}
+	if l := *c.Retrieval.MMRLambda; l < 0 || l > 1 {
+		return fmt.Errorf("retrieval.mmr_lambda %v is outside [0, 1]", l)
+	}
+	if w := *c.Extractive.LexicalWeight; w < 0 || w > 1 {
+		return fmt.Errorf("extractive.lexical_weight %v is outside [0, 1]", w)
+	}
+	if err := c.Ollama.validateModels(c.Generator.Model); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateModels checks that every chat model leaves room for the prompt
+// after the tokens reserved for the answer.
+func (c OllamaConfig) validateModels(generatorModel string) error {
+	models := []string{generatorModel}
+	for name := range c.Models {
+		if name != generatorModel {
+			models = append(models, name)
+		}
+	}
+	sort.Strings(models[1:])
+	for _, name := range models {
+		mc := c.ChatModelConfig(name)
+		if mc.NumCtx <= mc.AnswerTokens {
+			return fmt.Errorf("chat model %s: num_ctx %d must be larger than answer_tokens %d", name, mc.NumCtx, mc.AnswerTokens)
+		}
+	}
+	return nil
+}
+
+// defaultMaxDistance is the default relevance threshold. It is calibrated for
+// the unnormalized vectors nomic-embed-text returns from /api/embeddings;
+// other embedding models need their own value (see the distances ask -v prints).
+const defaultMaxDistance = 20.0
+
+// applyDefaults fills in zero values that have a sensible default
+func (c *Config) applyDefaults() {
+	if c.Grounding.Method == "" {
+		c.Grounding.Method = groundingLexical
+	}
+	if c.Grounding.Threshold == 0 {
+		c.Grounding.Threshold = 0.5
+	}
+	if c.Extractive.Fallback == nil {
+		fallback := true
+		c.Extractive.Fallback = &fallback
+	}
+	if c.Extractive.Sentences == 0 {
+		c.Extractive.Sentences = 5
+	}
+	if c.Extractive.LexicalWeight == nil {
+		weight := 0.5
+		c.Extractive.LexicalWeight = &weight
+	}
+	if c.Agent.SubQuestions == 0 {
+		c.Agent.SubQuestions = 3
+	}
+	if c.Agent.Model == "" {
+		c.Agent.Model = c.Ollama.ChatModel
+	}
+	if c.Generator.Type == "" {
+		c.Generator.Type = generatorOllama
+	}
+	if c.Generator.URL == "" {
+		c.Generator.URL = "http://localhost:8000/v1"
+	}
+	if c.Generator.Model == "" {
+		c.Generator.Model = c.Ollama.ChatModel
+	}
+	if c.Retrieval.VectorWeight == nil {
+		weight := 1.0
+		c.Retrieval.VectorWeight = &weight
+	}
+	if c.Retrieval.TextWeight == nil {
+		weight := 1.0
+		c.Retrieval.TextWeight = &weight
+	}
+	if c.Retrieval.RRFK == 0 {
+		c.Retrieval.RRFK = 60
+	}
+	if c.Retrieval.Diversity == "" {
+		c.Retrieval.Diversity = "round_robin"
+	}
+	if c.Retrieval.MMRLambda == nil {
+		lambda := 0.7
+		c.Retrieval.MMRLambda = &lambda
+	}
+	if c.Retrieval.MaxDistance == nil {
+		maxDistance := defaultMaxDistance
+		c.Retrieval.MaxDistance = &maxDistance
+	}
+	if c.Retrieval.Mode == "" {
+		c.Retrieval.Mode = modeSingle
+	}
+	if c.Retrieval.Paraphrases == 0 {
+		c.Retrieval.Paraphrases = 3
+	}
+	if c.Retrieval.CrossLingual.Method == "" {
+		c.Retrieval.CrossLingual.Method = translateLLM
+	}
+	if len(c.Retrieval.CrossLingual.Languages) == 0 {
+		c.Retrieval.CrossLingual.Languages = []string{"zh", "en"}
+	}
+	if c.Classifier.Mode == "" {
+		c.Classifier.Mode = classifyRules
+	}
+	if c.Classifier.MinSimilarity == 0 {
+		c.Classifier.MinSimilarity = 0.6
+	}
+	if c.Classifier.DefaultType == "" {
+		c.Classifier.DefaultType = "通用"
+	}
+	if len(c.Classifier.QueryTypes) == 0 {
+		c.Classifier.QueryTypes = defaultQueryTypes()
+	}
+	if c.Rerank.Type == "" {
+		c.Rerank.Type = "none"
+	}
+	for _, rc := range []*RerankerConfig{&c.Rerank.Lexical, &c.Rerank.LLM, &c.Rerank.CrossEncoder} {
+		if rc.TopN == 0 {
+			rc.TopN = 20
+		}
+	}
+	if c.Rerank.LLM.Model == "" {
+		c.Rerank.LLM.Model = c.Generator.Model
+	}
+	if c.Rerank.CrossEncoder.URL == "" {
+		c.Rerank.CrossEncoder.URL = "http://localhost:8080/v1/rerank"
+	}
+	if c.Ollama.NumCtx == 0 {
+		c.Ollama.NumCtx = 4096
+	}
+	if c.Ollama.AnswerTokens == 0 {
+		c.Ollama.AnswerTokens = 1024
+	}
+}
+
 // GetDefaultConfigPath returns the default config file path
 func GetDefaultConfigPath() string {
 	// Check current directory first
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerationOptions(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+ollama:
+  num_ctx: 4096
+  options:
+    temperature: 0
+    top_p: 0.9
+    stop: ["</answer>"]
+`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"temperature": 0.0, "top_p": 0.9, "stop": []string{"</answer>"}}
+	if got := cfg.Ollama.Options.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() = %v, want %v", got, want)
+	}
+
+	// 命令行参数覆盖配置文件
+	var cli CLI
+	parser, err := kong.New(&cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.Parse([]string{"ask", "q", "--seed=42", "--temperature=0.5", "--num-ctx=8192", "--stop=a,b", "--stop=c"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Ollama.Options = cfg.Ollama.Options.Merge(cli.Ask.Generation)
+	want = map[string]any{"temperature": 0.5, "seed": 42, "top_p": 0.9, "num_ctx": 8192, "stop": []string{"a,b", "c"}}
+	if got := cfg.Ollama.Options.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("merged Map() = %v, want %v", got, want)
+	}
+	if mc := cfg.Ollama.ChatModelConfig("m"); mc.NumCtx != 8192 {
+		t.Errorf("num_ctx override not applied to the model: %d", mc.NumCtx)
+	}
+}
+
+func TestQACacheKeyIncludesOptions(t *testing.T) {
+	base := qaCacheKey("ollama", "m", "prompt", map[string]any{"num_ctx": 4096})
+	if qaCacheKey("ollama", "m", "prompt", map[string]any{"num_ctx": 4096}) != base {
+		t.Error("expected a stable key")
+	}
+	for _, key := range []string{
+		qaCacheKey("ollama", "m", "prompt", map[string]any{"num_ctx": 4096, "seed": 1}),
+		qaCacheKey("ollama", "m", "prompt", map[string]any{"num_ctx": 4096, "temperature": 0.0}),
+		qaCacheKey("ollama", "other", "prompt", map[string]any{"num_ctx": 4096}),
+		qaCacheKey("openai", "m", "prompt", map[string]any{"num_ctx": 4096}),
+	} {
+		if key == base {
+			t.Error("expected the backend, options and model to change the key")
+		}
+	}
+}
+
+func TestGenerationCacheKey(t *testing.T) {
+	// num_ctx由模型配置补上，同样要进入缓存键
+	small := &ollamaGenerator{url: "http://localhost:11434", model: "m", numCtx: 4096}
+	large := &ollamaGenerator{url: "http://localhost:11434", model: "m", numCtx: 8192}
+	if generationCacheKey(small, "prompt", GenerationOptions{}) == generationCacheKey(large, "prompt", GenerationOptions{}) {
+		t.Error("expected num_ctx to change the key")
+	}
+	// 同名模型在不同后端上的回答不共用缓存
+	openai := &openAIGenerator{url: "http://localhost:8000/v1", model: "m"}
+	if generationCacheKey(small, "prompt", GenerationOptions{}) == generationCacheKey(openai, "prompt", GenerationOptions{}) {
+		t.Error("expected the backend to change the key")
+	}
+	// OpenAI接口不发送num_ctx，覆盖它不影响缓存
+	numCtx := GenerationOptions{NumCtx: 16384}
+	if generationCacheKey(openai, "prompt", numCtx) != generationCacheKey(openai, "prompt", GenerationOptions{}) {
+		t.Error("num_ctx is not sent to OpenAI-compatible servers")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		yaml    string
+		wantErr string
+	}{
+		{"retrieval:\n  diversity: mmr\n  mmr_lambda: 0\n", ""},
+		{"retrieval:\n  diversity: mmr\n", ""},
+		{"retrieval:\n  diversity: mrr\n", "unknown retrieval.diversity"},
+		{"retrieval:\n  diversity: mmr\n  mmr_lambda: 1.5\n", "outside [0, 1]"},
+		{"extractive:\n  lexical_weight: 0\n", ""},
+		{"extractive:\n  lexical_weight: -1\n", "extractive.lexical_weight"},
+		{"retrieval:\n  text_weight: 0\n", ""},
+		{"retrieval:\n  vector_weight: -0.5\n", "retrieval.vector_weight"},
+		{"retrieval:\n  vector_weight: 0\n  text_weight: 0\n", "both 0"},
+		{"retrieval:\n  rrf_k: -60\n", "retrieval.rrf_k"},
+		{"classifier:\n  mode: embeddings\n", "unknown classifier.mode"},
+		{"classifier:\n  default_type: 其他\n", "classifier.default_type"},
+		{"classifier:\n  default_type: 其他\n  query_types:\n    - name: 其他\n", ""},
+		{"retrieval:\n  mode: multiquery\n", "unknown retrieval.mode"},
+		{"retrieval:\n  cross_lingual:\n    method: dictionary\n", "unknown retrieval.cross_lingual.method"},
+		{"rerank:\n  type: bm25\n", "unknown rerank.type"},
+		{"rerank:\n  type: cross_encoder\n", "rerank.cross_encoder.model"},
+		{"generator:\n  type: vllm\n", "unknown generator.type"},
+		{"grounding:\n  method: nli\n", "unknown grounding.method"},
+		{"ollama:\n  num_ctx: 1024\n  answer_tokens: 1024\n", "num_ctx 1024 must be larger than answer_tokens 1024"},
+		{"ollama:\n  models:\n    big:\n      num_ctx: 2048\n      answer_tokens: 4096\n", "chat model big"},
+	} {
+		var cfg Config
+		if err := yaml.Unmarshal([]byte(tc.yaml), &cfg); err != nil {
+			t.Fatal(err)
+		}
+		cfg.applyDefaults()
+		err := cfg.validate()
+		if tc.wantErr == "" && err != nil || tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("validate(%q) = %v, want %q", tc.yaml, err, tc.wantErr)
+		}
+	}
+
+	// λ=0 表示只看多样性，不能被默认值替换
+	var cfg Config
+	yaml.Unmarshal([]byte("retrieval:\n  mmr_lambda: 0\n"), &cfg)
+	cfg.applyDefaults()
+	if *cfg.Retrieval.MMRLambda != 0 {
+		t.Errorf("mmr_lambda: 0 was replaced with %v", *cfg.Retrieval.MMRLambda)
+	}
+}
+
+func TestShippedConfigIsValid(t *testing.T) {
+	if _, err := LoadConfig("../../config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+}
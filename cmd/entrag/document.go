@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// documentInfo 文档级别的元数据
+type documentInfo struct {
+	Title    string
+	Metadata map[string]string
+}
+
+// readDocumentInfo 读取文档的front matter和标题。标题依次取自front matter
+// 中的title、第一个Markdown标题，最后退回到文件名。front matter格式错误时
+// 只输出警告，文档不带元数据加载。
+func readDocumentInfo(path string) (documentInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return documentInfo{}, err
+	}
+	body := string(data)
+	info := documentInfo{Metadata: map[string]string{}}
+
+	if fm, rest, ok := splitFrontMatter(body); ok {
+		var raw map[string]any
+		if err := yaml.Unmarshal([]byte(fm), &raw); err != nil {
+			log.Printf("Warning: ignoring the malformed front matter of %s: %v", path, err)
+			raw = nil
+		}
+		for k, v := range raw {
+			info.Metadata[k] = frontMatterValue(v)
+		}
+		info.Title = info.Metadata["title"]
+		body = rest
+	}
+
+	if info.Title == "" {
+		scanner := bufio.NewScanner(strings.NewReader(body))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "#") {
+				info.Title = strings.TrimSpace(strings.TrimLeft(line, "#"))
+				break
+			}
+		}
+	}
+	if info.Title == "" {
+		info.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return info, nil
+}
+
+// splitFrontMatter 拆分以 --- 包围的YAML front matter
+func splitFrontMatter(body string) (string, string, bool) {
+	if !strings.HasPrefix(body, "---\n") && !strings.HasPrefix(body, "---\r\n") {
+		return "", body, false
+	}
+	rest := body[strings.Index(body, "\n")+1:]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return "", body, false
+	}
+	fm := rest[:end]
+	rest = rest[end+len("\n---"):]
+	if i := strings.Index(rest, "\n"); i >= 0 {
+		rest = rest[i+1:]
+	} else {
+		rest = ""
+	}
+	return fm, rest, true
+}
+
+// frontMatterValue 将front matter的值转换为字符串，列表以逗号连接
+func frontMatterValue(v any) string {
+	if list, ok := v.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// paragraph 以空行分隔的段落及其在原文中的行号（从1开始）
+type paragraph struct {
+	Text      string
+	StartLine int
+	EndLine   int
+}
+
+// splitParagraphs 按空行（"\n\n"）切分文本并去除首尾空白，记录每段的行号。
+// 只含空白的段落被跳过。
+func splitParagraphs(data string) []paragraph {
+	var paragraphs []paragraph
+	line := 1
+	for len(data) > 0 {
+		raw := data
+		advance := len(data)
+		if i := strings.Index(data, "\n\n"); i >= 0 {
+			raw, advance = data[:i], i+2
+		}
+		if text := strings.TrimSpace(raw); text != "" {
+			start := line + strings.Count(raw[:strings.Index(raw, text)], "\n")
+			paragraphs = append(paragraphs, paragraph{
+				Text:      text,
+				StartLine: start,
+				EndLine:   start + strings.Count(text, "\n"),
+			})
+		}
+		line += strings.Count(data[:advance], "\n")
+		data = data[advance:]
+	}
+	return paragraphs
+}
+
+// markdownHeadings 返回每一行所在的Markdown标题，下标为行号（从1开始）。
+// 代码块中以 # 开头的行不是标题。
+func markdownHeadings(data string) []string {
+	lines := strings.Split(data, "\n")
+	headings := make([]string, len(lines)+1)
+	current, fenced := "", false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fenced = !fenced
+		case !fenced && strings.HasPrefix(trimmed, "#"):
+			if h := strings.TrimSpace(strings.TrimLeft(trimmed, "#")); h != "" {
+				current = h
+			}
+		}
+		headings[i+1] = current
+	}
+	return headings
+}
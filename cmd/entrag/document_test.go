@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSplitParagraphs(t *testing.T) {
+	data := "# Title\n\nFirst paragraph\ncontinues here.\n\n\n\n  Indented second.\n\nlast"
+	got := splitParagraphs(data)
+
+	// 段落文本与按 "\n\n" 扫描的结果一致
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
+			return i + 2, bytes.TrimSpace(data[:i]), nil
+		}
+		if atEOF && len(data) != 0 {
+			return len(data), bytes.TrimSpace(data), nil
+		}
+		return 0, nil, nil
+	})
+	var want []string
+	for scanner.Scan() {
+		want = append(want, scanner.Text())
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d paragraphs, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Text != want[i] {
+			t.Errorf("paragraph %d = %q, want %q", i, got[i].Text, want[i])
+		}
+	}
+
+	lines := map[string][2]int{
+		"# Title":                          {1, 1},
+		"First paragraph\ncontinues here.": {3, 4},
+		"Indented second.":                 {8, 8},
+		"last":                             {10, 10},
+	}
+	for _, p := range got {
+		if want, ok := lines[p.Text]; ok && (p.StartLine != want[0] || p.EndLine != want[1]) {
+			t.Errorf("%q at lines %d-%d, want %d-%d", p.Text, p.StartLine, p.EndLine, want[0], want[1])
+		}
+	}
+}
+
+func TestMarkdownHeadings(t *testing.T) {
+	data := "intro\n# Schema\ntext\n```go\n# not a heading\n```\n## Edges\nmore"
+	headings := markdownHeadings(data)
+	want := []string{"", "", "Schema", "Schema", "Schema", "Schema", "Schema", "Edges", "Edges"}
+	for line := 1; line < len(want); line++ {
+		if headings[line] != want[line] {
+			t.Errorf("line %d heading = %q, want %q", line, headings[line], want[line])
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 检索模式
+const (
+	modeSingle     = "single"      // 只用问题本身检索
+	modeMultiQuery = "multi_query" // 问题 + 模型生成的改写
+	modeHyDE       = "hyde"        // 问题 + 模型生成的假想答案（Hypothetical Document Embeddings）
+)
+
+// queryVariant 用于检索的一个问题变体
+type queryVariant struct {
+	Kind string // original / paraphrase / hyde / translation
+	Lang string // 译文的语言，仅translation
+	Text string
+	Hits int // 该变体召回的片段数
+}
+
+// paraphrasePrompt 生成问题改写的prompt
+const paraphrasePrompt = `请将下面的问题改写为 %d 个不同的表述，用于在技术文档中检索相关内容。
+改写应保留原意，可以补充可能的术语、同义词或更完整的说法，使用与原问题相同的语言。
+每行输出一个改写，不要编号，不要输出其他内容。
+
+问题: %s`
+
+// hydePrompt 生成假想答案的prompt
+const hydePrompt = `请针对下面的问题写一段简短的技术文档段落（不超过150字）作为答案，
+就像它摘自项目文档一样。即使不确定也直接作答，不要说明或拒绝，使用与问题相同的语言。
+
+问题: %s`
+
+// listMarker 匹配行首的编号或列表符号
+var listMarker = regexp.MustCompile(`^\s*(?:\d+[.)、]|[-*•])\s*`)
+
+// generateVariants 按检索模式生成问题变体，第一个总是原问题。
+// generate为调用聊天模型的函数。
+func generateVariants(question, mode string, paraphrases int, generate func(prompt string) (string, error)) ([]queryVariant, error) {
+	variants := []queryVariant{{Kind: "original", Text: question}}
+	switch mode {
+	case "", modeSingle:
+		return variants, nil
+	case modeMultiQuery:
+		reply, err := generate(fmt.Sprintf(paraphrasePrompt, paraphrases, question))
+		if err != nil {
+			return variants, err
+		}
+		seen := map[string]bool{strings.ToLower(strings.TrimSpace(question)): true}
+		for _, line := range strings.Split(reply, "\n") {
+			line = strings.TrimSpace(listMarker.ReplaceAllString(line, ""))
+			key := strings.ToLower(line)
+			if line == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			variants = append(variants, queryVariant{Kind: "paraphrase", Text: line})
+			if len(variants) > paraphrases {
+				break
+			}
+		}
+		return variants, nil
+	case modeHyDE:
+		reply, err := generate(fmt.Sprintf(hydePrompt, question))
+		if err != nil {
+			return variants, err
+		}
+		if reply = strings.TrimSpace(reply); reply != "" {
+			variants = append(variants, queryVariant{Kind: "hyde", Text: reply})
+		}
+		return variants, nil
+	default:
+		return nil, fmt.Errorf("unknown retrieval mode %q", mode)
+	}
+}
+
+// searchVariants 为每个变体生成向量并并行执行混合检索，再用RRF融合各变体的结果。
+// 原问题的向量emb已经计算好；假想答案只参与向量检索，全文检索使用问题本身。
+func searchVariants(ctx context.Context, variants []queryVariant, emb []float32, embed func(string) ([]float32, error),
+	search func(ctx context.Context, emb []float32, text string) ([]*SearchHit, error), rrfK int) ([]*SearchHit, error) {
+	lists := make([][]*SearchHit, len(variants))
+	errs := make([]error, len(variants))
+	var wg sync.WaitGroup
+	for i, v := range variants {
+		wg.Add(1)
+		go func(i int, v queryVariant) {
+			defer wg.Done()
+			vEmb := emb
+			if v.Kind != "original" {
+				var err error
+				if vEmb, err = embed(v.Text); err != nil {
+					errs[i] = fmt.Errorf("embedding %s variant: %w", v.Kind, err)
+					return
+				}
+			}
+			text := v.Text
+			if v.Kind == "hyde" {
+				text = ""
+			}
+			lists[i], errs[i] = search(ctx, vEmb, text)
+		}(i, v)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range variants {
+		variants[i].Hits = len(lists[i])
+	}
+	if len(lists) == 1 {
+		return lists[0], nil
+	}
+	return fuseVariants(variants, lists, emb, rrfK), nil
+}
+
+// fuseVariants 使用RRF融合多个变体的检索结果。各路名次取最好的一次，
+// 距离统一按原问题的向量重新计算，以便相关性阈值保持一致。另一种语言的
+// 文档与原问题的向量距离天然偏大，因此译文召回的片段取与译文的距离。
+func fuseVariants(variants []queryVariant, lists [][]*SearchHit, emb []float32, rrfK int) []*SearchHit {
+	byChunk := make(map[int]*SearchHit)
+	var fused []*SearchHit
+	for i, list := range lists {
+		for rank, h := range list {
+			m, ok := byChunk[h.Chunk.ID]
+			if !ok {
+				m = &SearchHit{Chunk: h.Chunk, Distance: math.Inf(1)}
+				byChunk[h.Chunk.ID] = m
+				fused = append(fused, m)
+			}
+			// 先出现在仅全文命中的列表中时还没有向量，由后面的列表补上
+			if m.Embedding == nil && h.Embedding != nil {
+				m.Embedding = h.Embedding
+				m.Distance = math.Min(m.Distance, l2Distance(emb, m.Embedding.Embedding.Slice()))
+			}
+			if variants[i].Kind == "translation" && h.Distance < m.Distance {
+				m.Distance = h.Distance
+			}
+			m.VectorRank = bestRank(m.VectorRank, h.VectorRank)
+			m.TextRank = bestRank(m.TextRank, h.TextRank)
+			m.Score += 1 / float64(rrfK+rank+1)
+		}
+	}
+	sortHits(fused)
+	return fused
+}
+
+// bestRank 返回两个名次中较好的一个，0表示未命中
+func bestRank(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// countTranslations 返回译文变体的个数
+func countTranslations(variants []queryVariant) int {
+	n := 0
+	for _, v := range variants {
+		if v.Kind == "translation" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rotemtam/entrag/ent"
+)
+
+func TestGenerateVariants(t *testing.T) {
+	reply := "1. How do ent hooks work?\n- hooks?\n\n2) What are mutation hooks in ent?\n3、Ent hook usage\n4. extra"
+	variants, err := generateVariants("hooks?", modeMultiQuery, 3, func(string) (string, error) {
+		return reply, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"hooks?", "How do ent hooks work?", "What are mutation hooks in ent?", "Ent hook usage"}
+	if len(variants) != len(want) {
+		t.Fatalf("got %d variants, want %d: %+v", len(variants), len(want), variants)
+	}
+	for i, v := range variants {
+		if v.Text != want[i] {
+			t.Errorf("variant %d = %q, want %q", i, v.Text, want[i])
+		}
+	}
+
+	variants, err = generateVariants("hooks?", modeHyDE, 3, func(string) (string, error) {
+		return "Hooks are middleware for mutations.", nil
+	})
+	if err != nil || len(variants) != 2 || variants[1].Kind != "hyde" {
+		t.Fatalf("unexpected hyde variants %+v (%v)", variants, err)
+	}
+
+	// 生成失败时仍返回原问题
+	variants, err = generateVariants("hooks?", modeMultiQuery, 3, func(string) (string, error) {
+		return "", fmt.Errorf("offline")
+	})
+	if err == nil || len(variants) != 1 {
+		t.Fatalf("expected fallback to the question, got %+v (%v)", variants, err)
+	}
+}
+
+func TestSearchVariantsFusion(t *testing.T) {
+	chunks := map[int]*ent.Chunk{}
+	for id := 1; id <= 3; id++ {
+		chunks[id] = &ent.Chunk{ID: id, Path: "doc.md", Nchunk: id}
+	}
+	// 每个变体召回的chunk ID
+	results := map[string][]int{
+		"hooks?":        {1, 2},
+		"ent hooks":     {2, 3},
+		"mutation hook": {2},
+	}
+	variants := []queryVariant{
+		{Kind: "original", Text: "hooks?"},
+		{Kind: "paraphrase", Text: "ent hooks"},
+		{Kind: "paraphrase", Text: "mutation hook"},
+	}
+	search := func(_ context.Context, _ []float32, text string) ([]*SearchHit, error) {
+		var hits []*SearchHit
+		for i, id := range results[text] {
+			hits = append(hits, &SearchHit{Chunk: chunks[id], VectorRank: i + 1})
+		}
+		return hits, nil
+	}
+	embed := func(string) ([]float32, error) {
+		return []float32{1}, nil
+	}
+	fused, err := searchVariants(context.Background(), variants, []float32{1}, embed, search, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fused) != 3 || fused[0].Chunk.ID != 2 {
+		t.Fatalf("expected chunk 2 first among 3, got %+v", fused)
+	}
+	if fused[0].VectorRank != 1 {
+		t.Errorf("expected best vector rank 1, got %d", fused[0].VectorRank)
+	}
+	if variants[1].Hits != 2 || variants[2].Hits != 1 {
+		t.Errorf("unexpected per-variant hit counts %+v", variants)
+	}
+}
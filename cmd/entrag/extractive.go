@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// extractiveCandidates 每个要输出的句子最多计算多少个候选句子的向量，
+// 其余句子只按词汇重叠排序，避免为长文档逐句调用向量模型
+const extractiveCandidates = 4
+
+// extract 抽取式回答中的一个句子
+type extract struct {
+	Passage  int     `json:"passage"` // 句子所在段落的编号，即引用编号
+	Text     string  `json:"text"`
+	Score    float64 `json:"score"`
+	Lexical  float64 `json:"lexical"`  // 问题词项在句子中出现的比例
+	Semantic float64 `json:"semantic"` // 句子与问题向量的余弦相似度，没有向量时为0
+	// Spans 为句子中与问题词项匹配的字节区间 [start, end)
+	Spans [][2]int `json:"spans"`
+}
+
+// extractSentences 按问题对段落中的句子排序，返回得分最高的句子。
+// 句子得分为词汇重叠与向量相似度的加权和；embed为nil或生成向量失败时只使用词汇重叠。
+func extractSentences(question string, passages []*Passage, cfg ExtractiveConfig, embed func(text string) ([]float32, error)) []extract {
+	terms := make(map[string]bool)
+	questionTokens := uniqueTokens(tokenize(question))
+	for _, t := range questionTokens {
+		terms[t] = true
+	}
+
+	// 相邻段落可能包含相同的句子，只保留第一次出现
+	var candidates []extract
+	seen := make(map[string]bool)
+	for i, p := range passages {
+		for _, sentence := range splitClaims(p.Text) {
+			if seen[sentence] {
+				continue
+			}
+			seen[sentence] = true
+			c := extract{Passage: i + 1, Text: sentence}
+			if len(questionTokens) > 0 {
+				c.Lexical = float64(countKeywordMatches(questionTokens, sentence)) / float64(len(questionTokens))
+			}
+			c.Score = c.Lexical
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Lexical > candidates[j].Lexical })
+
+	// 词汇重叠最高的候选句子再按向量相似度重新打分；任何一个向量生成失败时
+	// 所有句子都只按词汇重叠排序，避免两种得分混在一起比较
+	if embed != nil && len(candidates) > 0 {
+		top := candidates[:min(len(candidates), cfg.Sentences*extractiveCandidates)]
+		if similarities, err := embedSimilarities(question, top, embed); err != nil {
+			log.Printf("Warning: extractive answer without embeddings: %v", err)
+		} else {
+			w := *cfg.LexicalWeight
+			for i := range candidates {
+				c := &candidates[i]
+				if i < len(similarities) {
+					c.Semantic = similarities[i]
+				}
+				c.Score = w*c.Lexical + (1-w)*c.Semantic
+			}
+			sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+		}
+	}
+
+	var extracts []extract
+	for _, c := range candidates {
+		if len(extracts) == cfg.Sentences || c.Score <= 0 {
+			break
+		}
+		c.Spans = highlightSpans(c.Text, terms)
+		extracts = append(extracts, c)
+	}
+	return extracts
+}
+
+// embedSimilarities 返回各候选句子与问题向量的余弦相似度，任何一个向量生成失败时返回错误
+func embedSimilarities(question string, candidates []extract, embed func(text string) ([]float32, error)) ([]float64, error) {
+	qEmb, err := embed(question)
+	if err != nil {
+		return nil, err
+	}
+	similarities := make([]float64, len(candidates))
+	for i, c := range candidates {
+		emb, err := embed(c.Text)
+		if err != nil {
+			return nil, err
+		}
+		similarities[i] = cosineSimilarity(qEmb, emb)
+	}
+	return similarities, nil
+}
+
+// highlightSpans 返回文本中与词项匹配的字节区间：英文单词或标识符按其词项整体匹配，
+// 中日韩文字按二元组（或单字）匹配，相邻或重叠的区间合并为一个
+func highlightSpans(text string, terms map[string]bool) [][2]int {
+	var spans [][2]int
+	add := func(start, end int) {
+		if n := len(spans); n > 0 && start <= spans[n-1][1] {
+			spans[n-1][1] = max(spans[n-1][1], end)
+			return
+		}
+		spans = append(spans, [2]int{start, end})
+	}
+
+	runes := []rune(text)
+	offsets := make([]int, len(runes)+1) // 每个rune的字节偏移
+	for i, r := range runes {
+		offsets[i+1] = offsets[i] + utf8.RuneLen(r)
+	}
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case isCJK(r):
+			j := i
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			for k := i; k < j; k++ {
+				if k+1 < j && terms[string(runes[k:k+2])] {
+					add(offsets[k], offsets[k+2])
+				} else if terms[string(runes[k])] {
+					add(offsets[k], offsets[k+1])
+				}
+			}
+			i = j
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && (isWordRune(runes[j]) || isJoiner(runes, j)) {
+				j++
+			}
+			for _, t := range wordTokens(string(runes[i:j])) {
+				if terms[t] {
+					add(offsets[i], offsets[j])
+					break
+				}
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return spans
+}
+
+// highlight 将区间内的文本加粗
+func highlight(text string, spans [][2]int) string {
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(text[last:s[0]])
+		b.WriteString("**" + text[s[0]:s[1]] + "**")
+		last = s[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// formatExtractiveAnswer 将抽取的句子按段落分组为Markdown回答：段落按其最高得分排序，
+// 段落内的句子保持得分顺序，每句后附引用编号
+func formatExtractiveAnswer(extracts []extract, passages []*Passage) string {
+	var order []int
+	groups := make(map[int][]extract)
+	for _, e := range extracts {
+		if groups[e.Passage] == nil {
+			order = append(order, e.Passage)
+		}
+		groups[e.Passage] = append(groups[e.Passage], e)
+	}
+	var b strings.Builder
+	for i, n := range order {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "**%s**\n\n", sourceLabel(passages[n-1]))
+		for _, e := range groups[n] {
+			fmt.Fprintf(&b, "- %s [%d]\n", highlight(e.Text, e.Spans), n)
+		}
+	}
+	return b.String()
+}
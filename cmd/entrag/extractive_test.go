@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractSentences(t *testing.T) {
+	passages := []*Passage{
+		{Path: "a.md", Text: "# Hooks\nHooks run before or after a mutation. Ent supports many databases."},
+		{Path: "b.md", Text: "A mutation hook receives the next mutator. Hooks run before or after a mutation."},
+	}
+	weight := 0.5
+	cfg := ExtractiveConfig{Sentences: 2, LexicalWeight: &weight}
+
+	// 没有向量时只按词汇重叠排序，重复的句子只保留一次，不相关的句子不输出
+	extracts := extractSentences("When do hooks run?", passages, cfg, nil)
+	if len(extracts) != 2 || extracts[0].Text != "Hooks run before or after a mutation." || extracts[0].Passage != 1 {
+		t.Fatalf("unexpected extracts %+v", extracts)
+	}
+	if extracts[1].Passage != 2 || !strings.Contains(extracts[1].Text, "mutation hook") {
+		t.Errorf("unexpected second extract %+v", extracts[1])
+	}
+
+	// 向量相似度可以改变排序
+	embed := func(text string) ([]float32, error) {
+		if strings.Contains(text, "next mutator") || strings.HasSuffix(text, "?") {
+			return []float32{1, 0}, nil
+		}
+		return []float32{0, 1}, nil
+	}
+	extracts = extractSentences("When do hooks run?", passages, cfg, embed)
+	if extracts[0].Passage != 2 || extracts[0].Semantic != 1 {
+		t.Errorf("expected the semantically closest sentence first, got %+v", extracts)
+	}
+
+	// 向量模型不可用时退回词汇重叠
+	failing := func(string) ([]float32, error) { return nil, errors.New("down") }
+	if got := extractSentences("When do hooks run?", passages, cfg, failing); got[0].Passage != 1 {
+		t.Errorf("unexpected extracts without embeddings %+v", got)
+	}
+
+	// 中途失败时所有句子都只按词汇重叠打分，不混用两种得分
+	calls := 0
+	partial := func(text string) ([]float32, error) {
+		if calls++; calls > 2 {
+			return nil, errors.New("down")
+		}
+		return embed(text)
+	}
+	for _, e := range extractSentences("When do hooks run?", passages, cfg, partial) {
+		if e.Score != e.Lexical || e.Semantic != 0 {
+			t.Errorf("expected lexical-only scores after a failure, got %+v", e)
+		}
+	}
+
+	// lexical_weight为0时只按向量相似度排序
+	zero := 0.0
+	extracts = extractSentences("When do hooks run?", passages, ExtractiveConfig{Sentences: 2, LexicalWeight: &zero}, embed)
+	if len(extracts) != 1 || extracts[0].Score != 1 || extracts[0].Passage != 2 {
+		t.Errorf("unexpected semantic-only extracts %+v", extracts)
+	}
+}
+
+func TestHighlightSpans(t *testing.T) {
+	terms := make(map[string]bool)
+	for _, t := range tokenize("如何定义边 edges") {
+		terms[t] = true
+	}
+	text := "使用 edge.To 定义边，Edges 表示实体之间的关系。"
+	spans := highlightSpans(text, terms)
+	if got, want := highlight(text, spans), "使用 edge.To **定义边**，**Edges** 表示实体之间的关系。"; got != want {
+		t.Errorf("highlight = %q, want %q", got, want)
+	}
+	if spans := highlightSpans("nothing here", terms); spans != nil {
+		t.Errorf("expected no spans, got %v", spans)
+	}
+}
+
+func TestFormatExtractiveAnswer(t *testing.T) {
+	passages := []*Passage{{Path: "a.md"}, {Path: "b.md", Heading: "Hooks", StartLine: 3, EndLine: 9}}
+	extracts := []extract{
+		{Passage: 2, Text: "Hooks run first.", Spans: [][2]int{{0, 5}}},
+		{Passage: 1, Text: "Other sentence."},
+		{Passage: 2, Text: "Hooks run later."},
+	}
+	want := "**b.md § Hooks (L3-9)**\n\n- **Hooks** run first. [2]\n- Hooks run later. [2]\n\n**a.md**\n\n- Other sentence. [1]\n"
+	if got := formatExtractiveAnswer(extracts, passages); got != want {
+		t.Errorf("formatExtractiveAnswer =\n%s\nwant\n%s", got, want)
+	}
+	if !reflect.DeepEqual(checkCitations(want, 2).Cited, []int{2, 1}) {
+		t.Error("extractive answers must cite their passages")
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/rotemtam/entrag/ent/chunk"
+	"github.com/rotemtam/entrag/ent/predicate"
+)
+
+// SearchFilter 检索时的元数据过滤条件，会下推到SQL的WHERE子句中
+type SearchFilter struct {
+	Collection string            `help:"Only search this collection from config; its prompt overrides are used as well."`
+	Path       string            `help:"Only search chunks whose path has this prefix, or matches this glob (* and ?)."`
+	Title      string            `help:"Only search documents whose title contains this text (case-insensitive)."`
+	Lang       string            `name:"doc-lang" help:"Only search chunks in this language (e.g. en, zh)."`
+	Meta       map[string]string `help:"Only search documents whose front matter has KEY=VALUE. Repeatable."`
+}
+
+// Empty 判断是否没有任何过滤条件
+func (f SearchFilter) Empty() bool {
+	return f.Collection == "" && len(f.predicates()) == 0
+}
+
+// resolve 将collection展开为其路径条件，已指定path时以path为准
+func (f SearchFilter) resolve(collections map[string]CollectionConfig) (SearchFilter, error) {
+	if f.Collection == "" {
+		return f, nil
+	}
+	c, ok := collections[f.Collection]
+	if !ok {
+		return f, fmt.Errorf("unknown collection %q", f.Collection)
+	}
+	if f.Path == "" {
+		f.Path = c.Path
+	}
+	return f, nil
+}
+
+// String 返回过滤条件的可读描述
+func (f SearchFilter) String() string {
+	var parts []string
+	if f.Collection != "" {
+		parts = append(parts, "collection="+f.Collection)
+	}
+	if f.Path != "" {
+		parts = append(parts, "path="+f.Path)
+	}
+	if f.Title != "" {
+		parts = append(parts, "title~"+f.Title)
+	}
+	if f.Lang != "" {
+		parts = append(parts, "lang="+f.Lang)
+	}
+	keys := make([]string, 0, len(f.Meta))
+	for k := range f.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, f.Meta[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// predicates 将过滤条件转换为chunk上的ent谓词
+func (f SearchFilter) predicates() []predicate.Chunk {
+	var preds []predicate.Chunk
+	if f.Path != "" {
+		if strings.ContainsAny(f.Path, "*?") {
+			preds = append(preds, func(s *sql.Selector) {
+				s.Where(sql.Like(s.C(chunk.FieldPath), globToLike(f.Path)))
+			})
+		} else {
+			preds = append(preds, chunk.PathHasPrefix(f.Path))
+		}
+	}
+	if f.Title != "" {
+		preds = append(preds, chunk.TitleContainsFold(f.Title))
+	}
+	if f.Lang != "" {
+		preds = append(preds, chunk.LangEQ(f.Lang))
+	}
+	if len(f.Meta) > 0 {
+		// 使用 @> 包含查询，以便利用metadata上的GIN索引（jsonb_path_ops）
+		meta, _ := json.Marshal(f.Meta)
+		preds = append(preds, func(s *sql.Selector) {
+			s.Where(sql.P(func(b *sql.Builder) {
+				b.Ident(s.C(chunk.FieldMetadata)).WriteString(" @> ").Arg(string(meta)).WriteString("::jsonb")
+			}))
+		})
+	}
+	return preds
+}
+
+// globToLike 将glob模式转换为SQL LIKE模式：* 和 ** 匹配任意字符，? 匹配单个字符
+func globToLike(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteByte('%')
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+		case '?':
+			b.WriteByte('_')
+		case '%', '_', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
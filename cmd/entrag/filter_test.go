@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"github.com/rotemtam/entrag/ent/chunk"
+)
+
+func TestGlobToLike(t *testing.T) {
+	for glob, want := range map[string]string{
+		"data/versioned/*.md": "data/versioned/%.md",
+		"data/**/0?-*.mdx":    "data/%/0_-%.mdx",
+		"data/cn/pdm_capp.*":  `data/cn/pdm\_capp.%`,
+	} {
+		if got := globToLike(glob); got != want {
+			t.Errorf("globToLike(%q) = %q, want %q", glob, got, want)
+		}
+	}
+}
+
+func TestReadDocumentInfo(t *testing.T) {
+	info, err := readDocumentInfo(filepath.Join("..", "..", "data", "versioned", "01-intro.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "Introduction" || info.Metadata["id"] != "intro" {
+		t.Errorf("readDocumentInfo() = %+v", info)
+	}
+
+	info, err = readDocumentInfo(filepath.Join("..", "..", "data", "cn", "pdm.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Title != "pdm" || len(info.Metadata) != 0 {
+		t.Errorf("readDocumentInfo() = %+v", info)
+	}
+}
+
+func TestReadDocumentInfoMalformedFrontMatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.md")
+	if err := os.WriteFile(path, []byte("---\ntitle: [unclosed\n---\n# Hooks\n\nbody\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 格式错误的front matter不会中断加载，文档不带元数据，标题取自第一个标题
+	info, err := readDocumentInfo(path)
+	if err != nil || info.Title != "Hooks" || len(info.Metadata) != 0 {
+		t.Errorf("readDocumentInfo() = %+v, %v", info, err)
+	}
+}
+
+func TestMetadataPredicateUsesContainment(t *testing.T) {
+	f := SearchFilter{Meta: map[string]string{"id": "intro", "tags": "go"}}
+	s := sql.Dialect(dialect.Postgres).Select("*").From(sql.Table(chunk.Table))
+	for _, p := range f.predicates() {
+		p(s)
+	}
+	query, args := s.Query()
+	// jsonb_path_ops的GIN索引只能用于 @> 包含查询
+	if !strings.Contains(query, `"metadata" @> $1::jsonb`) || len(args) != 1 || args[0] != `{"id":"intro","tags":"go"}` {
+		t.Errorf("unexpected query %s %v", query, args)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for text, want := range map[string]string{
+		"PDM是 Product Data Management(产品数据管理)的缩写。": "zh",
+		"Ent supports two different workflows":     "en",
+		"スキーマの定義":                                  "ja",
+		"```\n123\n```":                            "",
+	} {
+		if got := detectLanguage(text); got != want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Generator 聊天模型后端，回答生成以及查询扩展、翻译、重排序、问题拆分等
+// 内部调用都通过它访问模型。回答以流式方式生成：onToken不为nil时逐段接收
+// 到达的文本；生成未完成（被取消或连接中断）时返回已生成的部分和错误。
+type Generator interface {
+	// Model 返回使用的模型名
+	Model() string
+	// Backend 返回后端类型和地址，用于区分不同后端上的同名模型
+	Backend() string
+	// RequestOptions 返回实际随请求发送给模型的生成参数
+	RequestOptions(opts GenerationOptions) map[string]any
+	// Generate 根据单个prompt生成回答
+	Generate(ctx context.Context, prompt string, opts GenerationOptions, onToken func(string)) (string, error)
+	// Chat 根据system/user/assistant消息列表生成回答
+	Chat(ctx context.Context, messages []ChatMessage, opts GenerationOptions, onToken func(string)) (string, error)
+}
+
+// 生成回答的后端类型
+const (
+	generatorOllama = "ollama" // Ollama的 /api/generate 和 /api/chat
+	generatorOpenAI = "openai" // OpenAI兼容的 /v1/chat/completions（vLLM、llama.cpp server、LM Studio等）
+)
+
+// newGenerator 按配置创建生成回答的后端
+func newGenerator(cfg *Config) (Generator, error) {
+	return newModelGenerator(cfg, cfg.Generator.Model)
+}
+
+// newModelGenerator 使用配置的后端为指定模型创建Generator
+func newModelGenerator(cfg *Config, model string) (Generator, error) {
+	gc := cfg.Generator
+	switch gc.Type {
+	case generatorOllama:
+		return &ollamaGenerator{url: cfg.Ollama.URL, model: model, numCtx: cfg.Ollama.ChatModelConfig(model).NumCtx}, nil
+	case generatorOpenAI:
+		return &openAIGenerator{url: strings.TrimSuffix(gc.URL, "/"), model: model, apiKey: gc.APIKey}, nil
+	default:
+		return nil, fmt.Errorf("unknown generator type %q", gc.Type)
+	}
+}
+
+// generatorFor 返回使用指定模型的Generator：与g的模型相同时直接使用g，
+// 否则在配置的后端上为该模型创建一个
+func generatorFor(cfg *Config, g Generator, model string) (Generator, error) {
+	if model == "" || model == g.Model() {
+		return g, nil
+	}
+	return newModelGenerator(cfg, model)
+}
+
+// generationCacheKey 返回问答缓存键。后端、模型和实际发送的生成参数
+// （包括Ollama按模型配置补上的num_ctx）不同时回答也不同，因此都包含在键中。
+func generationCacheKey(g Generator, prompt string, opts GenerationOptions) string {
+	return qaCacheKey(g.Backend(), g.Model(), prompt, g.RequestOptions(opts))
+}
+
+// cachedGenerate 使用问答缓存生成回复，不输出过程信息，用于查询扩展、
+// 翻译和问题拆分等内部调用，使相同问题的变体保持稳定并避免重复调用模型
+func cachedGenerate(ctx context.Context, g Generator, prompt string, opts GenerationOptions) (string, error) {
+	reply, _, err := generateAnswer(ctx, io.Discard, g, prompt, opts, nil)
+	return reply, err
+}
+
+// generateAnswer 使用问答缓存生成回答，过程信息输出到w。缓存命中时整个回答
+// 作为一段交给onToken；只有完整生成的回答才写入缓存，取消ctx即中止生成。
+func generateAnswer(ctx context.Context, w io.Writer, g Generator, prompt string, opts GenerationOptions, onToken func(string)) (answer string, cacheHit bool, err error) {
+	if onToken == nil {
+		onToken = func(string) {}
+	}
+
+	// 生成缓存键
+	cacheKey := generationCacheKey(g, prompt, opts)
+
+	// 尝试从缓存获取
+	if cachedAnswer, found := qaCache.Get(cacheKey); found {
+		fmt.Fprintf(w, "   💾 使用问答缓存 (问答缓存大小: %d)\n", qaCache.Size())
+		fmt.Fprintf(w, "   📝 上下文长度: %d 字符\n", len(prompt))
+		fmt.Fprintf(w, "   🤖 使用模型: %s\n", g.Model())
+		fmt.Fprintf(w, "   📊 响应长度: %d 字符\n", len(cachedAnswer))
+		onToken(cachedAnswer)
+		return cachedAnswer, true, nil
+	}
+
+	// 记录请求的详细信息
+	fmt.Fprintf(w, "   🔄 未找到问答缓存，调用LLM API (问答缓存大小: %d)\n", qaCache.Size())
+	fmt.Fprintf(w, "   📝 上下文长度: %d 字符\n", len(prompt))
+	fmt.Fprintf(w, "   🤖 使用模型: %s\n", g.Model())
+
+	// 记录到第一段回答为止的时间（包含网络请求和模型加载）
+	requestStart := time.Now()
+	first := true
+	answer, err = g.Generate(ctx, prompt, opts, func(token string) {
+		if first {
+			first = false
+			fmt.Fprintf(w, "   📊 网络请求时间: %v\n", time.Since(requestStart))
+		}
+		onToken(token)
+	})
+	if err != nil {
+		return answer, false, err
+	}
+
+	// 只缓存完整生成的结果
+	qaCache.Set(cacheKey, answer)
+	return answer, false, nil
+}
+
+// postJSON 发送JSON请求，返回状态为200的响应
+func postJSON(ctx context.Context, url string, body any, header http.Header) (*http.Response, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %s", string(body))
+	}
+	return resp, nil
+}
+
+// readStream 逐行读取流式响应。parse解析一行，返回新生成的文本以及生成是否完成；
+// 流在完成前结束时返回错误。
+func readStream(ctx context.Context, body io.Reader, parse func(line []byte) (token string, done bool, err error), onToken func(string)) (string, error) {
+	var b strings.Builder
+	done := false
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for !done && scanner.Scan() {
+		token, end, err := parse(scanner.Bytes())
+		if err != nil {
+			return b.String(), err
+		}
+		if token != "" {
+			b.WriteString(token)
+			if onToken != nil {
+				onToken(token)
+			}
+		}
+		done = end
+	}
+	if err := ctx.Err(); err != nil {
+		return b.String(), err
+	}
+	if err := scanner.Err(); err != nil {
+		return b.String(), fmt.Errorf("error reading response: %w", err)
+	}
+	if !done {
+		return b.String(), fmt.Errorf("response ended before generation completed")
+	}
+	return b.String(), nil
+}
+
+// roleContent 只保留消息的role和content
+func roleContent(messages []ChatMessage) []ChatMessage {
+	msgs := make([]ChatMessage, len(messages))
+	for i, m := range messages {
+		msgs[i] = ChatMessage{Role: m.Role, Content: m.Content}
+	}
+	return msgs
+}
+
+// ollamaGenerator 使用Ollama生成回答
+type ollamaGenerator struct {
+	url    string
+	model  string
+	numCtx int // 未在生成参数中指定时使用的上下文窗口
+}
+
+// Model 返回使用的模型名
+func (g *ollamaGenerator) Model() string { return g.model }
+
+// Backend 返回后端类型和地址
+func (g *ollamaGenerator) Backend() string { return generatorOllama + " " + g.url }
+
+// RequestOptions 将生成参数转换为Ollama的请求参数，未指定num_ctx时使用模型的上下文窗口
+func (g *ollamaGenerator) RequestOptions(opts GenerationOptions) map[string]any {
+	options := opts.Map()
+	if _, ok := options["num_ctx"]; !ok && g.numCtx > 0 {
+		options["num_ctx"] = g.numCtx
+	}
+	return options
+}
+
+// Generate 调用 /api/generate，流式响应每行是一个JSON对象，最后一个的done为true
+func (g *ollamaGenerator) Generate(ctx context.Context, prompt string, opts GenerationOptions, onToken func(string)) (string, error) {
+	resp, err := postJSON(ctx, g.url+"/api/generate", OllamaChatRequest{
+		Model:   g.model,
+		Prompt:  prompt,
+		Stream:  true,
+		Options: g.RequestOptions(opts),
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return readStream(ctx, resp.Body, func(line []byte) (string, bool, error) {
+		var chatResp OllamaChatResponse
+		if err := json.Unmarshal(line, &chatResp); err != nil {
+			return "", false, fmt.Errorf("error decoding response: %v", err)
+		}
+		if chatResp.Error != "" {
+			return "", false, fmt.Errorf("API error: %s", chatResp.Error)
+		}
+		return chatResp.Response, chatResp.Done, nil
+	}, onToken)
+}
+
+// Chat 调用 /api/chat
+func (g *ollamaGenerator) Chat(ctx context.Context, messages []ChatMessage, opts GenerationOptions, onToken func(string)) (string, error) {
+	resp, err := postJSON(ctx, g.url+"/api/chat", OllamaMessagesRequest{
+		Model:    g.model,
+		Messages: roleContent(messages),
+		Stream:   true,
+		Options:  g.RequestOptions(opts),
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return readStream(ctx, resp.Body, func(line []byte) (string, bool, error) {
+		var chatResp OllamaMessagesResponse
+		if err := json.Unmarshal(line, &chatResp); err != nil {
+			return "", false, fmt.Errorf("error decoding response: %v", err)
+		}
+		if chatResp.Error != "" {
+			return "", false, fmt.Errorf("API error: %s", chatResp.Error)
+		}
+		return chatResp.Message.Content, chatResp.Done, nil
+	}, onToken)
+}
+
+// OpenAIChatRequest is the request of the OpenAI-compatible /v1/chat/completions endpoint.
+type OpenAIChatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Stream      bool          `json:"stream"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	Seed        *int          `json:"seed,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+	Stop        []string      `json:"stop,omitempty"`
+}
+
+// OpenAIChatChunk is one server-sent event of a streamed chat completion.
+type OpenAIChatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// openAIGenerator 使用本地运行的OpenAI兼容服务生成回答
+type openAIGenerator struct {
+	url    string // 包含 /v1 的基础URL
+	model  string
+	apiKey string
+}
+
+// Model 返回使用的模型名
+func (g *openAIGenerator) Model() string { return g.model }
+
+// Backend 返回后端类型和地址
+func (g *openAIGenerator) Backend() string { return generatorOpenAI + " " + g.url }
+
+// RequestOptions 返回发送的采样参数。OpenAI接口没有num_ctx，上下文窗口由服务端决定。
+func (g *openAIGenerator) RequestOptions(opts GenerationOptions) map[string]any {
+	options := opts.Map()
+	delete(options, "num_ctx")
+	return options
+}
+
+// Generate 将prompt作为一条user消息调用Chat
+func (g *openAIGenerator) Generate(ctx context.Context, prompt string, opts GenerationOptions, onToken func(string)) (string, error) {
+	return g.Chat(ctx, []ChatMessage{{Role: "user", Content: prompt}}, opts, onToken)
+}
+
+// Chat 调用 /chat/completions，流式响应为server-sent events，以 "data: [DONE]" 结束。
+// OpenAI接口没有num_ctx，上下文窗口由服务端决定。
+func (g *openAIGenerator) Chat(ctx context.Context, messages []ChatMessage, opts GenerationOptions, onToken func(string)) (string, error) {
+	header := make(http.Header)
+	if g.apiKey != "" {
+		header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+	resp, err := postJSON(ctx, g.url+"/chat/completions", OpenAIChatRequest{
+		Model:       g.model,
+		Messages:    roleContent(messages),
+		Stream:      true,
+		Temperature: opts.Temperature,
+		Seed:        opts.Seed,
+		TopP:        opts.TopP,
+		Stop:        opts.Stop,
+	}, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return readStream(ctx, resp.Body, func(line []byte) (string, bool, error) {
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			return "", false, nil // 空行、注释和event字段
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			return "", true, nil
+		}
+		var chunk OpenAIChatChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return "", false, fmt.Errorf("error decoding response: %v", err)
+		}
+		if chunk.Error != nil {
+			return "", false, fmt.Errorf("API error: %s", chunk.Error.Message)
+		}
+		var token string
+		for _, c := range chunk.Choices {
+			token += c.Delta.Content
+		}
+		return token, false, nil
+	}, onToken)
+}
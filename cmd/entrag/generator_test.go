@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// scriptedGenerator 按顺序返回预设回答的Generator，并记录收到的prompt
+type scriptedGenerator struct {
+	answers []string
+	prompts []string
+}
+
+// Model 返回固定的模型名
+func (g *scriptedGenerator) Model() string { return "scripted" }
+
+// Backend 返回固定的后端名
+func (g *scriptedGenerator) Backend() string { return "scripted" }
+
+// RequestOptions 原样返回生成参数
+func (g *scriptedGenerator) RequestOptions(opts GenerationOptions) map[string]any { return opts.Map() }
+
+// Generate 返回下一个预设回答，按空格分段交给onToken
+func (g *scriptedGenerator) Generate(ctx context.Context, prompt string, opts GenerationOptions, onToken func(string)) (string, error) {
+	g.prompts = append(g.prompts, prompt)
+	if len(g.answers) == 0 {
+		return "", errors.New("scripted generator: no answers left")
+	}
+	answer := g.answers[0]
+	g.answers = g.answers[1:]
+	if onToken != nil {
+		for _, token := range strings.SplitAfter(answer, " ") {
+			onToken(token)
+		}
+	}
+	return answer, nil
+}
+
+// Chat 以最后一条消息作为prompt
+func (g *scriptedGenerator) Chat(ctx context.Context, messages []ChatMessage, opts GenerationOptions, onToken func(string)) (string, error) {
+	return g.Generate(ctx, messages[len(messages)-1].Content, opts, onToken)
+}
+
+func TestOllamaGeneratorChat(t *testing.T) {
+	var req OllamaMessagesRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"你好"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"！"},"done":true}`)
+	}))
+	defer srv.Close()
+
+	g := &ollamaGenerator{url: srv.URL, model: "m", numCtx: 8192}
+	seed := 7
+	answer, err := g.Chat(context.Background(), []ChatMessage{{Role: "user", Content: "hi", Query: "hi"}}, GenerationOptions{Seed: &seed}, nil)
+	if err != nil || answer != "你好！" {
+		t.Fatalf("Chat() = %q, %v", answer, err)
+	}
+	if !req.Stream || req.Messages[0].Query != "" {
+		t.Errorf("expected a streamed request with role and content only, got %+v", req)
+	}
+	if req.Options["num_ctx"] != 8192.0 || req.Options["seed"] != 7.0 {
+		t.Errorf("unexpected options %v", req.Options)
+	}
+}
+
+func TestOpenAIGenerator(t *testing.T) {
+	var req map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, `data: {"choices":[{"delta":{"role":"assistant","content":"Ent is "},"finish_reason":null}]}`+"\n\n")
+		fmt.Fprint(w, `data: {"choices":[{"delta":{"content":"an ORM [1]."},"finish_reason":"stop"}]}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	cfg := &Config{Generator: GeneratorConfig{Type: generatorOpenAI, URL: srv.URL + "/v1/", Model: "qwen2.5", APIKey: "secret"}}
+	g, err := newGenerator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	temperature := 0.0
+	var tokens []string
+	answer, err := g.Generate(context.Background(), "What is Ent?", GenerationOptions{Temperature: &temperature, NumCtx: 8192}, func(s string) {
+		tokens = append(tokens, s)
+	})
+	if err != nil || answer != "Ent is an ORM [1]." || len(tokens) != 2 {
+		t.Fatalf("Generate() = %q (tokens %q), %v", answer, tokens, err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("unexpected Authorization header %q", auth)
+	}
+	if req["model"] != "qwen2.5" || req["temperature"] != 0.0 || req["stream"] != true {
+		t.Errorf("unexpected request %v", req)
+	}
+	if _, ok := req["num_ctx"]; ok {
+		t.Errorf("num_ctx must not be sent to an OpenAI-compatible server")
+	}
+}
+
+func TestOpenAIGeneratorIncomplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `data: {"choices":[{"delta":{"content":"Ent"}}]}`+"\n\n")
+	}))
+	defer srv.Close()
+
+	g := &openAIGenerator{url: srv.URL, model: "m"}
+	if _, err := g.Generate(context.Background(), "q", GenerationOptions{}, nil); err == nil {
+		t.Fatal("expected an error for a stream without [DONE]")
+	}
+}
+
+func TestNewGeneratorUnknownType(t *testing.T) {
+	if _, err := newGenerator(&Config{Generator: GeneratorConfig{Type: "anthropic"}}); err == nil {
+		t.Fatal("expected an error for an unknown generator type")
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 事实核查方式
+const (
+	groundingLexical = "lexical" // 与段落的词汇重叠
+	groundingLLM     = "llm"     // 由聊天模型逐句判断
+)
+
+// leadingCitations 匹配文本开头的引用标记，如 " [1][2]"
+var leadingCitations = regexp.MustCompile(`^(?:\s*` + citationPattern.String() + `)+`)
+
+// minClaimTokens 少于该词数的句子不作为待核查的陈述
+const minClaimTokens = 3
+
+// judgePrompt 让模型判断一句话能否由文档段落支持的prompt
+const judgePrompt = `下面是一些技术文档段落和一句话。请判断这句话的内容是否能由文档段落直接支持。
+只回答 "是" 或 "否"，不要解释。
+
+文档段落:
+%s
+句子: %s`
+
+// claim 回答中的一个待核查陈述
+type claim struct {
+	Text      string  `json:"text"`
+	Supported bool    `json:"supported"`
+	Score     float64 `json:"score"`             // 支持程度，lexical为词汇重叠比例，llm为0或1
+	Passage   int     `json:"passage,omitempty"` // 最能支持该陈述的段落编号（lexical）
+}
+
+// groundingReport 回答的事实核查结果
+type groundingReport struct {
+	Method string  `json:"method"`
+	Score  float64 `json:"score"` // 有文档支持的陈述占比，没有陈述时为1
+	Claims []claim `json:"claims"`
+}
+
+// Unsupported 返回没有文档支持的陈述
+func (r *groundingReport) Unsupported() []claim {
+	var unsupported []claim
+	for _, c := range r.Claims {
+		if !c.Supported {
+			unsupported = append(unsupported, c)
+		}
+	}
+	return unsupported
+}
+
+// splitClaims 将回答切分为句子，跳过代码块、标题和过短的句子。
+// 句子以中英文句末标点或换行结束；英文句点后须跟空白，以免切开 entsql.IndexType 或 1.5 这样的文本。
+func splitClaims(answer string) []string {
+	var claims []string
+	add := func(s string) {
+		s = strings.TrimSpace(listMarker.ReplaceAllString(strings.TrimSpace(s), ""))
+		// 标题和以冒号结尾的引导句（如"总结如下："）不是陈述
+		if s == "" || strings.HasPrefix(s, "#") || strings.HasSuffix(s, "：") || strings.HasSuffix(s, ":") {
+			return
+		}
+		if len(tokenize(citationPattern.ReplaceAllString(s, ""))) >= minClaimTokens {
+			claims = append(claims, s)
+		}
+	}
+
+	inCode := false
+	for _, line := range strings.Split(answer, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		runes := []rune(line)
+		start := 0
+		for i, r := range runes {
+			end := strings.ContainsRune("。！？!?；;", r) ||
+				(r == '.' && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])))
+			if !end {
+				continue
+			}
+			// 句末之后的引用标记属于这句话
+			j := i + 1
+			rest := string(runes[j:])
+			if loc := leadingCitations.FindStringIndex(rest); loc != nil {
+				j += utf8.RuneCountInString(rest[:loc[1]])
+			}
+			add(string(runes[start:j]))
+			start = j
+		}
+		add(string(runes[start:]))
+	}
+	return claims
+}
+
+// checkLexical 以陈述的词在单个段落中出现的比例衡量支持程度，取最高的段落
+func checkLexical(claims []string, passages []*Passage, threshold float64) []claim {
+	passageTokens := make([]map[string]bool, len(passages))
+	for i, p := range passages {
+		passageTokens[i] = make(map[string]bool)
+		for _, t := range tokenize(p.Text) {
+			passageTokens[i][t] = true
+		}
+	}
+	results := make([]claim, len(claims))
+	for i, text := range claims {
+		results[i] = claim{Text: text}
+		tokens := uniqueTokens(tokenize(citationPattern.ReplaceAllString(text, "")))
+		for n, pt := range passageTokens {
+			found := 0
+			for _, t := range tokens {
+				if pt[t] {
+					found++
+				}
+			}
+			if score := float64(found) / float64(len(tokens)); score > results[i].Score {
+				results[i].Score = score
+				results[i].Passage = n + 1
+			}
+		}
+		results[i].Supported = results[i].Score >= threshold
+	}
+	return results
+}
+
+// checkLLM 由模型逐句判断陈述是否有文档支持
+func checkLLM(claims []string, passages []*Passage, judge func(prompt string) (string, error)) ([]claim, error) {
+	var context strings.Builder
+	for i, p := range passages {
+		context.WriteString(formatPassage(i+1, p))
+	}
+	results := make([]claim, len(claims))
+	for i, text := range claims {
+		reply, err := judge(fmt.Sprintf(judgePrompt, context.String(), citationPattern.ReplaceAllString(text, "")))
+		if err != nil {
+			return nil, fmt.Errorf("judging claim %d: %w", i+1, err)
+		}
+		results[i] = claim{Text: text, Supported: parseJudgement(reply)}
+		if results[i].Supported {
+			results[i].Score = 1
+		}
+	}
+	return results, nil
+}
+
+// parseJudgement 解析模型的判断，以 "是"/"yes"/"supported" 开头视为支持
+func parseJudgement(reply string) bool {
+	reply = strings.ToLower(strings.TrimLeft(strings.TrimSpace(reply), "\"'“*"))
+	for _, yes := range []string{"是", "yes", "supported", "true"} {
+		if strings.HasPrefix(reply, yes) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyAnswer 对回答进行事实核查。judge仅在llm方式下使用。
+func verifyAnswer(answer string, passages []*Passage, cfg GroundingConfig, judge func(prompt string) (string, error)) (*groundingReport, error) {
+	claims := splitClaims(answer)
+	report := &groundingReport{Method: cfg.Method, Score: 1, Claims: []claim{}}
+	if len(claims) == 0 {
+		return report, nil
+	}
+	switch cfg.Method {
+	case groundingLexical:
+		report.Claims = checkLexical(claims, passages, cfg.Threshold)
+	case groundingLLM:
+		results, err := checkLLM(claims, passages, judge)
+		if err != nil {
+			return nil, err
+		}
+		report.Claims = results
+	default:
+		return nil, fmt.Errorf("unknown grounding method %q", cfg.Method)
+	}
+	supported := len(claims) - len(report.Unsupported())
+	report.Score = float64(supported) / float64(len(claims))
+	return report, nil
+}
+
+// cachedVerifyAnswer 与verifyAnswer相同，结果以JSON保存在问答缓存中。
+// answerKey为回答的缓存键，回答不变时核查结果也不变。
+func cachedVerifyAnswer(answerKey, answer string, passages []*Passage, cfg GroundingConfig, judge func(prompt string) (string, error)) (*groundingReport, bool, error) {
+	cacheKey := getCacheKey(fmt.Sprintf("grounding\n%s\n%g\n%s", cfg.Method, cfg.Threshold, answerKey))
+	if cached, found := qaCache.Get(cacheKey); found {
+		var report groundingReport
+		if err := json.Unmarshal([]byte(cached), &report); err == nil {
+			return &report, true, nil
+		}
+	}
+	report, err := verifyAnswer(answer, passages, cfg, judge)
+	if err != nil {
+		return nil, false, err
+	}
+	if data, err := json.Marshal(report); err == nil {
+		qaCache.Set(cacheKey, string(data))
+	}
+	return report, false, nil
+}
+
+// printGrounding 输出事实核查结果，标出没有文档支持的句子
+func printGrounding(w io.Writer, report *groundingReport) {
+	unsupported := report.Unsupported()
+	fmt.Fprintf(w, "🔎 事实核查 (%s): %d/%d 句有文档支持, groundedness %.2f\n",
+		report.Method, len(report.Claims)-len(unsupported), len(report.Claims), report.Score)
+	for _, c := range unsupported {
+		fmt.Fprintf(w, "   ❓ %s\n", c.Text)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitClaims(t *testing.T) {
+	answer := "## 概述\n" +
+		"Ent是Go的实体框架 [1]。它使用代码生成。[2]\n" +
+		"总结如下：\n" +
+		"- Use entsql.IndexType to set the index type [1]. Then run go generate.\n" +
+		"```go\n" +
+		"field.String(\"name\")\n" +
+		"```\n"
+	want := []string{
+		"Ent是Go的实体框架 [1]。",
+		"它使用代码生成。[2]",
+		"Use entsql.IndexType to set the index type [1].",
+		"Then run go generate.",
+	}
+	if got := splitClaims(answer); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitClaims() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestVerifyAnswerLexical(t *testing.T) {
+	passages := []*Passage{
+		{Path: "a.md", Text: "Edges define the relations between entities. Use edge.To to declare an edge."},
+		{Path: "b.md", Text: "Hooks run before or after a mutation."},
+	}
+	answer := "Edges define the relations between entities [1]. " +
+		"Hooks run after a mutation [2]. " +
+		"Ent automatically shards the database across regions."
+	report, err := verifyAnswer(answer, passages, GroundingConfig{Method: groundingLexical, Threshold: 0.5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Claims) != 3 {
+		t.Fatalf("expected 3 claims, got %+v", report.Claims)
+	}
+	if !report.Claims[0].Supported || report.Claims[0].Passage != 1 || !report.Claims[1].Supported || report.Claims[1].Passage != 2 {
+		t.Errorf("expected the first two claims to be supported: %+v", report.Claims)
+	}
+	unsupported := report.Unsupported()
+	if len(unsupported) != 1 || !strings.Contains(unsupported[0].Text, "shards") {
+		t.Errorf("expected the invented claim to be unsupported: %+v", unsupported)
+	}
+	if report.Score < 0.66 || report.Score > 0.67 {
+		t.Errorf("score = %v, want 2/3", report.Score)
+	}
+}
+
+func TestVerifyAnswerLLM(t *testing.T) {
+	passages := []*Passage{{Path: "a.md", Text: "Hooks run before or after a mutation."}}
+	var prompts []string
+	judge := func(prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if strings.Contains(prompt, "Rust") {
+			return "否。文档没有提到。", nil
+		}
+		return "是", nil
+	}
+	report, err := verifyAnswer("Hooks run before a mutation [1]. Hooks can be written in Rust.", passages, GroundingConfig{Method: groundingLLM}, judge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 2 || !strings.Contains(prompts[0], "[1] From file: a.md") || strings.Contains(prompts[0], "mutation [1]") {
+		t.Errorf("unexpected judge prompts %q", prompts)
+	}
+	if report.Score != 0.5 || !report.Claims[0].Supported || report.Claims[1].Supported {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestParseJudgement(t *testing.T) {
+	for reply, want := range map[string]bool{"是": true, "Yes.": true, "**是**": true, "否": false, "No": false, "不确定": false} {
+		if got := parseJudgement(reply); got != want {
+			t.Errorf("parseJudgement(%q) = %v, want %v", reply, got, want)
+		}
+	}
+}
+
+func TestCachedVerifyAnswer(t *testing.T) {
+	useTempQACache(t)
+	passages := []*Passage{{Path: "a.md", Text: "Hooks run before or after a mutation."}}
+	calls := 0
+	judge := func(string) (string, error) {
+		calls++
+		return "yes", nil
+	}
+	cfg := GroundingConfig{Method: groundingLLM}
+	for i := 0; i < 2; i++ {
+		report, cached, err := cachedVerifyAnswer("key", "Hooks run before a mutation.", passages, cfg, judge)
+		if err != nil || report.Score != 1 || cached != (i == 1) {
+			t.Fatalf("run %d: report %+v, cached %v, err %v", i, report, cached, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the cached report to be reused, judge called %d times", calls)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+
+	"github.com/rotemtam/entrag/ent"
+	"github.com/rotemtam/entrag/ent/chunk"
+)
+
+// detectLanguage 根据文字系统粗略判断文本语言，返回 "zh"、"ja"、"ko"、"en"，
+// 无法判断（没有字母）时返回空串。中日韩文字占字母总数的20%以上即视为该语言，
+// 因为技术文档中的中文段落通常夹杂大量英文术语和代码。
+func detectLanguage(text string) string {
+	var han, kana, hangul, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.IsLetter(r):
+			latin++
+		}
+	}
+	cjk := han + kana + hangul
+	total := cjk + latin
+	if total == 0 {
+		return ""
+	}
+	if cjk*5 < total {
+		return "en"
+	}
+	switch {
+	case kana > 0:
+		return "ja"
+	case hangul > han:
+		return "ko"
+	}
+	return "zh"
+}
+
+// backfillLanguages 为旧版本load时没有记录语言的chunk检测并保存语言
+func backfillLanguages(ctx context.Context, client *ent.Client) (int, error) {
+	chunks, err := client.Chunk.Query().Where(chunk.LangIsNil()).All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range chunks {
+		if err := client.Chunk.UpdateOne(c).SetLang(detectLanguage(c.Data)).Exec(ctx); err != nil {
+			return 0, fmt.Errorf("updating chunk %d: %w", c.ID, err)
+		}
+	}
+	return len(chunks), nil
+}
@@ -14,12 +14,16 @@ type CLI struct {
 	Config string `kong:"help='Path to configuration file.',default='config.yaml'"`
 
 	// Subcommands
-	Load     *LoadCmd     `kong:"cmd,help='Load command that accepts a path.'"`
-	Index    *IndexCmd    `kong:"cmd,help='Create embeddings for any chunks that do not have one.'"`
-	Ask      *AskCmd      `kong:"cmd,help='Ask a question about the indexed documents'"`
-	Stats    *StatsCmd    `kong:"cmd,help='Show statistics about chunks and embeddings'"`
-	Cleanup  *CleanupCmd  `kong:"cmd,help='Remove orphaned chunks and optimize the database'"`
-	Optimize *OptimizeCmd `kong:"cmd,help='Optimize system performance and warm up caches'"`
+	Load      *LoadCmd      `kong:"cmd,help='Load command that accepts a path.'"`
+	Index     *IndexCmd     `kong:"cmd,help='Create embeddings for any chunks that do not have one.'"`
+	Ask       *AskCmd       `kong:"cmd,help='Ask a question about the indexed documents'"`
+	Search    *SearchCmd    `kong:"cmd,help='Search the indexed documents without generating an answer'"`
+	Chat      *ChatCmd      `kong:"cmd,help='Chat about the indexed documents with follow-up questions'"`
+	Prompt    *PromptCmd    `kong:"cmd,help='Render the prompt for a question without calling the chat model'"`
+	Summarize *SummarizeCmd `kong:"cmd,help='Summarize a document or all documents under a path prefix'"`
+	Stats     *StatsCmd     `kong:"cmd,help='Show statistics about chunks and embeddings'"`
+	Cleanup   *CleanupCmd   `kong:"cmd,help='Remove orphaned chunks and optimize the database'"`
+	Optimize  *OptimizeCmd  `kong:"cmd,help='Optimize system performance and warm up caches'"`
 
 	// Internal config (loaded from file)
 	cfg *Config `kong:"-"`
@@ -0,0 +1,118 @@
+package main
+
+import "math"
+
+// selectMMR 使用最大边际相关性（Maximal Marginal Relevance）选择片段：
+//
+//	MMR(d) = λ·rel(d) − (1−λ)·max sim(d, s)，s为已选片段
+//
+// rel为归一化后的融合得分（经过重排序时为重排序得分），sim为片段向量的余弦相似度（缺少向量时退化为
+// 词项的Jaccard相似度）。λ越小越强调多样性，可避免chunk_overlap产生的
+// 相邻重叠片段同时进入上下文。结果按得分排序。
+func selectMMR(hits []*SearchHit, maxResults int, lambda float64) []*SearchHit {
+	if len(hits) <= maxResults {
+		return hits
+	}
+
+	// 归一化相关性得分到[0,1]
+	minScore, maxScore := math.Inf(1), math.Inf(-1)
+	for _, h := range hits {
+		minScore = math.Min(minScore, rankScore(h))
+		maxScore = math.Max(maxScore, rankScore(h))
+	}
+	relevance := make([]float64, len(hits))
+	for i, h := range hits {
+		if maxScore > minScore {
+			relevance[i] = (rankScore(h) - minScore) / (maxScore - minScore)
+		} else {
+			relevance[i] = 1
+		}
+	}
+
+	// 片段间相似度按需计算并缓存
+	tokenSets := make([]map[string]bool, len(hits))
+	similarity := func(i, j int) float64 {
+		a, b := hits[i].Embedding, hits[j].Embedding
+		if a != nil && b != nil {
+			return cosineSimilarity(a.Embedding.Slice(), b.Embedding.Slice())
+		}
+		for _, k := range []int{i, j} {
+			if tokenSets[k] == nil {
+				tokenSets[k] = make(map[string]bool)
+				for _, t := range tokenize(hits[k].Chunk.Data) {
+					tokenSets[k][t] = true
+				}
+			}
+		}
+		return jaccard(tokenSets[i], tokenSets[j])
+	}
+	maxSim := make([]float64, len(hits))
+	selected := make([]bool, len(hits))
+
+	var result []*SearchHit
+	for len(result) < maxResults {
+		best, bestScore := -1, math.Inf(-1)
+		for i := range hits {
+			if selected[i] {
+				continue
+			}
+			score := lambda*relevance[i] - (1-lambda)*maxSim[i]
+			// 同分时保留靠前（得分更高）的候选
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		selected[best] = true
+		result = append(result, hits[best])
+		for i := range hits {
+			if !selected[i] {
+				maxSim[i] = math.Max(maxSim[i], similarity(i, best))
+			}
+		}
+	}
+
+	sortHits(result)
+	return result
+}
+
+// cosineSimilarity 计算两个向量的余弦相似度
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// jaccard 计算两个词项集合的Jaccard相似度
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for t := range a {
+		if b[t] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// rankScore 返回决定命中排序的得分：经过重排序时为重排序得分，否则为融合得分
+func rankScore(h *SearchHit) float64 {
+	if h.RerankScore != nil {
+		return *h.RerankScore
+	}
+	return h.Score
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/rotemtam/entrag/ent"
+)
+
+func TestSelectMMRSkipsNearDuplicates(t *testing.T) {
+	hit := func(id int, score float64, vec ...float32) *SearchHit {
+		return &SearchHit{
+			Chunk:     &ent.Chunk{ID: id, Path: "data/hooks.md", Nchunk: id},
+			Embedding: &ent.Embedding{Embedding: pgvector.NewVector(vec)},
+			Score:     score,
+		}
+	}
+	hits := []*SearchHit{
+		hit(1, 0.9, 1, 0, 0),
+		hit(2, 0.8, 0.99, 0.01, 0), // 与1几乎相同（相邻重叠片段）
+		hit(3, 0.7, 0, 1, 0),
+		hit(4, 0.6, 0, 0, 1),
+	}
+	got := selectMMR(hits, 3, 0.5)
+	ids := map[int]bool{}
+	for _, h := range got {
+		ids[h.Chunk.ID] = true
+	}
+	if len(got) != 3 || !ids[1] || ids[2] || !ids[3] || !ids[4] {
+		t.Errorf("selectMMR() selected %v, want chunks 1, 3 and 4", ids)
+	}
+
+	// λ=1时退化为按相关性选择
+	got = selectMMR(hits, 2, 1)
+	if got[0].Chunk.ID != 1 || got[1].Chunk.ID != 2 {
+		t.Errorf("selectMMR(λ=1) = [%d %d], want [1 2]", got[0].Chunk.ID, got[1].Chunk.ID)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// progressOut 接收向量缓存等过程信息。ask --format json 时改为stderr，
+// 使stdout只包含JSON文档。
+var progressOut io.Writer = os.Stdout
+
+// 回答方式
+const (
+	answerGenerated  = "generated"  // 由聊天模型生成
+	answerExtractive = "extractive" // 从段落中抽取句子
+)
+
+// askOutput ask --format json 输出的JSON文档
+type askOutput struct {
+	Question  string `json:"question"`
+	QueryType string `json:"query_type"`
+	Answer    string `json:"answer"`
+	// AnswerMode 为generated或extractive，没有检索到片段时为空
+	AnswerMode string    `json:"answer_mode,omitempty"`
+	Extracts   []extract `json:"extracts,omitempty"`
+	// GenerationError 为生成失败或超时的原因，此时回答改为抽取式
+	GenerationError string `json:"generation_error,omitempty"`
+	// NoContext 为true时没有检索到相关片段，也没有调用模型
+	NoContext        bool          `json:"no_context"`
+	Citations        []askCitation `json:"citations"`
+	InvalidCitations []int         `json:"invalid_citations,omitempty"`
+	Template         string        `json:"template,omitempty"`
+	// CodeChecks 回答中每个go代码块的检查结果
+	CodeChecks []codeCheck `json:"code_checks,omitempty"`
+	// Groundedness 为有文档支持的句子占比，未进行事实核查时省略
+	Groundedness  *float64         `json:"groundedness,omitempty"`
+	Grounding     *groundingReport `json:"grounding,omitempty"`
+	RetrievalMode string           `json:"retrieval_mode"`
+	// AgentTrace 为代理检索的子问题和每次检索，仅ask --agent
+	AgentTrace *agentTrace `json:"agent_trace,omitempty"`
+	Timings    askTimings  `json:"timings_ms"`
+	Models     askModels   `json:"models"`
+	Cache      askCache    `json:"cache"`
+}
+
+// askCitation prompt中的一个编号段落
+type askCitation struct {
+	N int `json:"n"`
+	// Cited 表示回答中引用了该段落
+	Cited bool `json:"cited"`
+	// ChunkID和Nchunk为段落中排序最靠前的命中chunk
+	ChunkID    int     `json:"chunk_id"`
+	Path       string  `json:"path"`
+	Nchunk     int     `json:"nchunk"`
+	FirstChunk int     `json:"first_nchunk"`
+	LastChunk  int     `json:"last_nchunk"`
+	Heading    string  `json:"heading,omitempty"`
+	StartLine  int     `json:"start_line,omitempty"`
+	EndLine    int     `json:"end_line,omitempty"`
+	Score      float64 `json:"score"`
+	// RerankScore 只有经过重排序时输出
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+	// Distance 只有全文检索命中时为null（距离为+Inf）
+	Distance *float64 `json:"distance"`
+}
+
+// askTimings 各阶段耗时（毫秒）
+type askTimings struct {
+	Embedding  float64 `json:"embedding"`
+	Search     float64 `json:"search"`
+	Expand     float64 `json:"expand,omitempty"`
+	Recall     float64 `json:"recall"`
+	Rerank     float64 `json:"rerank,omitempty"`
+	Context    float64 `json:"context"`
+	Generation float64 `json:"generation"`
+	Grounding  float64 `json:"grounding,omitempty"`
+	Total      float64 `json:"total"`
+}
+
+// askModels 使用的模型
+type askModels struct {
+	Generator string `json:"generator"`
+	Chat      string `json:"chat"`
+	Embedding string `json:"embedding"`
+	Reranker  string `json:"reranker,omitempty"`
+}
+
+// askCache 缓存命中情况
+type askCache struct {
+	Embedding bool `json:"embedding"`
+	Answer    bool `json:"answer"`
+	Grounding bool `json:"grounding,omitempty"`
+}
+
+// newAskCitations 将段落转换为JSON中的引用列表
+func newAskCitations(passages []*Passage, check citationCheck) []askCitation {
+	citations := make([]askCitation, len(passages))
+	for i, p := range passages {
+		best := bestHit(p)
+		citations[i] = askCitation{
+			N:           i + 1,
+			Cited:       check.IsCited(i + 1),
+			ChunkID:     best.Chunk.ID,
+			Path:        p.Path,
+			Nchunk:      best.Chunk.Nchunk,
+			FirstChunk:  p.FirstChunk,
+			LastChunk:   p.LastChunk,
+			Heading:     p.Heading,
+			StartLine:   p.StartLine,
+			EndLine:     p.EndLine,
+			Score:       p.Score,
+			RerankScore: best.RerankScore,
+			Distance:    finite(p.Distance),
+		}
+	}
+	return citations
+}
+
+// finite 返回f的指针，f为NaN或无穷大时返回nil（JSON中为null）
+func finite(f float64) *float64 {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil
+	}
+	return &f
+}
+
+// milliseconds 将耗时转换为毫秒
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// writeJSON 以缩进格式输出JSON文档
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
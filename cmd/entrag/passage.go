@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/rotemtam/entrag/ent"
+)
+
+// Passage 是放入prompt的一段连续上下文，由同一文件中一个或多个相邻chunk合并而成
+type Passage struct {
+	Path       string
+	Title      string
+	Heading    string            // 段落开始处所在的Markdown标题
+	Lang       string            // 文本语言
+	Metadata   map[string]string // 文档的front matter
+	FirstChunk int               // 起始nchunk
+	LastChunk  int               // 结束nchunk
+	StartLine  int               // 在原文中的起始行，旧数据为0
+	EndLine    int               // 在原文中的结束行
+	Text       string            // 去除重叠后的合并文本
+	Hits       []*SearchHit      // 其中被检索命中的chunk
+	Score      float64           // 命中chunk的最高得分
+	Distance   float64           // 命中chunk的最小距离
+}
+
+// buildPassages 将命中的chunk及其邻居按文件合并为段落。同一文件中nchunk连续的
+// chunk合并为一段，并去除chunk_overlap产生的重复文本。段落按其最佳命中的顺序排列。
+func buildPassages(hits []*SearchHit, neighbors []*ent.Chunk) []*Passage {
+	byPath := make(map[string]map[int]*ent.Chunk)
+	add := func(c *ent.Chunk) {
+		if byPath[c.Path] == nil {
+			byPath[c.Path] = make(map[int]*ent.Chunk)
+		}
+		byPath[c.Path][c.Nchunk] = c
+	}
+	hitByChunk := make(map[int]*SearchHit)
+	for _, h := range hits {
+		add(h.Chunk)
+		hitByChunk[h.Chunk.ID] = h
+	}
+	for _, c := range neighbors {
+		add(c)
+	}
+
+	var passages []*Passage
+	for path, chunks := range byPath {
+		nums := make([]int, 0, len(chunks))
+		for n := range chunks {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+
+		var p *Passage
+		for i, n := range nums {
+			c := chunks[n]
+			if p == nil || n != nums[i-1]+1 {
+				p = &Passage{Path: path, Title: c.Title, Heading: c.Heading, Lang: c.Lang, Metadata: c.Metadata, FirstChunk: n, StartLine: c.StartLine, Distance: math.Inf(1)}
+				passages = append(passages, p)
+				p.Text = c.Data
+			} else {
+				p.Text = mergeOverlap(p.Text, c.Data)
+			}
+			p.LastChunk = n
+			p.EndLine = max(p.EndLine, c.EndLine)
+			if h, ok := hitByChunk[c.ID]; ok {
+				p.Hits = append(p.Hits, h)
+				p.Score = math.Max(p.Score, h.Score)
+				p.Distance = math.Min(p.Distance, h.Distance)
+			}
+		}
+	}
+
+	// 调用方传入的邻居可能与命中chunk不相邻，丢弃不含命中的段落
+	kept := passages[:0]
+	for _, p := range passages {
+		if len(p.Hits) > 0 {
+			kept = append(kept, p)
+		}
+	}
+	passages = kept
+	sort.SliceStable(passages, func(i, j int) bool {
+		return hitLess(bestHit(passages[i]), bestHit(passages[j]))
+	})
+	return passages
+}
+
+// bestHit 返回段落中排序最靠前的命中
+func bestHit(p *Passage) *SearchHit {
+	best := p.Hits[0]
+	for _, h := range p.Hits[1:] {
+		if hitLess(h, best) {
+			best = h
+		}
+	}
+	return best
+}
+
+// expandPassages 为每个命中chunk取前后各window个相邻chunk，合并为段落。
+// 邻居按与命中chunk的距离由近到远、按命中顺序依次加入，加入后超出
+// tokenBudget的邻居会被跳过，同侧更远的邻居也不再加入，保证段落连续。
+// window为0时不扩展。
+func expandPassages(ctx context.Context, r Retriever, hits []*SearchHit, window, tokenBudget int, countTokens func(string) int) ([]*Passage, error) {
+	if window <= 0 || len(hits) == 0 {
+		return buildPassages(hits, nil), nil
+	}
+	candidates, err := r.Neighbors(ctx, hits, window)
+	if err != nil {
+		return nil, err
+	}
+	return buildPassages(hits, pickNeighbors(hits, candidates, window, tokenBudget, countTokens)), nil
+}
+
+// pickNeighbors 在token预算内选择要加入的邻居chunk
+func pickNeighbors(hits []*SearchHit, candidates []*ent.Chunk, window, tokenBudget int, countTokens func(string) int) []*ent.Chunk {
+	type key struct {
+		path string
+		n    int
+	}
+	available := make(map[key]*ent.Chunk, len(candidates))
+	for _, c := range candidates {
+		available[key{c.Path, c.Nchunk}] = c
+	}
+	included := make(map[key]bool)
+	used := 0
+	for _, h := range hits {
+		included[key{h.Chunk.Path, h.Chunk.Nchunk}] = true
+		used += countTokens(h.Chunk.Data)
+	}
+
+	var picked []*ent.Chunk
+	for d := 1; d <= window; d++ {
+		for _, h := range hits {
+			for _, step := range []int{-1, 1} {
+				k := key{h.Chunk.Path, h.Chunk.Nchunk + step*d}
+				c, ok := available[k]
+				// 同侧更近的邻居没有加入时跳过，否则段落与命中chunk不相邻
+				if !ok || included[k] || !included[key{h.Chunk.Path, k.n - step}] {
+					continue
+				}
+				tokens := countTokens(c.Data)
+				if tokenBudget > 0 && used+tokens > tokenBudget {
+					continue
+				}
+				included[k] = true
+				used += tokens
+				picked = append(picked, c)
+			}
+		}
+	}
+	return picked
+}
+
+// packPassages 按顺序（即得分从高到低）装入段落，直到用完tokenBudget。
+// 放不下的段落被跳过并返回给调用方，以便提示；若连第一个段落都放不下，
+// 则将其截断到预算以内，保证上下文不为空。
+func packPassages(passages []*Passage, tokenBudget int, countTokens func(string) int) (kept, dropped []*Passage) {
+	used := 0
+	for _, p := range passages {
+		tokens := countTokens(formatPassage(len(kept)+1, p))
+		if used+tokens <= tokenBudget {
+			kept = append(kept, p)
+			used += tokens
+			continue
+		}
+		dropped = append(dropped, p)
+	}
+	if len(kept) == 0 && len(passages) > 0 && tokenBudget > 0 {
+		first := *passages[0]
+		header := first
+		header.Text = ""
+		overhead := countTokens(formatPassage(1, &header))
+		first.Text = truncateToTokens(first.Text, tokenBudget-overhead, countTokens)
+		if first.Text != "" {
+			kept = []*Passage{&first}
+			dropped = dropped[1:]
+		}
+	}
+	return kept, dropped
+}
+
+// truncateToTokens 截取text的最长前缀，使其不超过maxTokens个token
+func truncateToTokens(text string, maxTokens int, countTokens func(string) int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if countTokens(string(runes[:mid])) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo])
+}
+
+// mergeOverlap 拼接相邻的两个chunk，去除b开头与a结尾重复的部分
+func mergeOverlap(a, b string) string {
+	maxK := len(a)
+	if len(b) < maxK {
+		maxK = len(b)
+	}
+	// 过短的重复可能只是巧合
+	const minOverlap = 16
+	for k := maxK; k >= minOverlap; k-- {
+		if a[len(a)-k] == b[0] && strings.HasSuffix(a, b[:k]) {
+			return a + b[k:]
+		}
+	}
+	if !strings.HasSuffix(a, "\n") {
+		a += "\n"
+	}
+	return a + b
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rotemtam/entrag/ent"
+)
+
+func TestMergeOverlap(t *testing.T) {
+	a := "The Hooks option allows adding custom logic before and after operations.\n"
+	b := "before and after operations.\nSchema hooks are defined in the schema.\n"
+	got := mergeOverlap(a, b)
+	want := "The Hooks option allows adding custom logic before and after operations.\nSchema hooks are defined in the schema.\n"
+	if got != want {
+		t.Errorf("mergeOverlap() = %q, want %q", got, want)
+	}
+	if got := mergeOverlap("first chunk", "second chunk"); got != "first chunk\nsecond chunk" {
+		t.Errorf("mergeOverlap() without overlap = %q", got)
+	}
+}
+
+func TestPickNeighborsAndBuildPassages(t *testing.T) {
+	chunks := make([]*ent.Chunk, 6)
+	for i := range chunks {
+		chunks[i] = &ent.Chunk{ID: i + 1, Path: "data/hooks.md", Nchunk: i, Data: strings.Repeat("x", 10) + string(rune('a'+i))}
+	}
+	hits := []*SearchHit{
+		{Chunk: chunks[1], Score: 0.9},
+		{Chunk: chunks[4], Score: 0.8},
+	}
+	countTokens := func(s string) int { return len(s) }
+
+	// 预算只够再加入两个邻居：距离1的邻居按命中顺序加入
+	picked := pickNeighbors(hits, chunks, 2, 4*11, countTokens)
+	var ns []int
+	for _, c := range picked {
+		ns = append(ns, c.Nchunk)
+	}
+	if len(ns) != 2 || ns[0] != 0 || ns[1] != 2 {
+		t.Fatalf("pickNeighbors() = %v, want [0 2]", ns)
+	}
+
+	passages := buildPassages(hits, picked)
+	if len(passages) != 2 {
+		t.Fatalf("buildPassages() returned %d passages, want 2", len(passages))
+	}
+	if p := passages[0]; p.FirstChunk != 0 || p.LastChunk != 2 || len(p.Hits) != 1 || p.Score != 0.9 {
+		t.Errorf("first passage = %+v", p)
+	}
+	if p := passages[1]; p.FirstChunk != 4 || p.LastChunk != 4 {
+		t.Errorf("second passage = %+v", p)
+	}
+}
+
+func TestPickNeighborsSkipsOverBudgetSide(t *testing.T) {
+	chunks := make([]*ent.Chunk, 8)
+	for i := range chunks {
+		chunks[i] = &ent.Chunk{ID: i + 1, Path: "data/hooks.md", Nchunk: i, Data: "x"}
+	}
+	chunks[4].Data = strings.Repeat("x", 100)
+	chunks[6].Data = strings.Repeat("x", 100)
+	hits := []*SearchHit{{Chunk: chunks[5], Score: 0.9}}
+	countTokens := func(s string) int { return len(s) }
+
+	// 距离1的邻居超出预算时，同侧距离2的邻居也不加入
+	if picked := pickNeighbors(hits, chunks, 2, 50, countTokens); len(picked) != 0 {
+		t.Fatalf("pickNeighbors() picked %d non-adjacent neighbours", len(picked))
+	}
+
+	// 不相邻的邻居不会产生没有命中的段落
+	passages := buildPassages(hits, []*ent.Chunk{chunks[3]})
+	if len(passages) != 1 || passages[0].FirstChunk != 5 || len(passages[0].Hits) != 1 {
+		t.Errorf("buildPassages() = %+v", passages)
+	}
+}
+
+func TestPackPassages(t *testing.T) {
+	countTokens := func(s string) int { return len(strings.Fields(s)) }
+	passages := []*Passage{
+		{Path: "a.md", Text: strings.Repeat("word ", 10)},
+		{Path: "b.md", Text: strings.Repeat("word ", 50)},
+		{Path: "c.md", Text: strings.Repeat("word ", 5)},
+	}
+	kept, dropped := packPassages(passages, 25, countTokens)
+	if len(kept) != 2 || kept[0].Path != "a.md" || kept[1].Path != "c.md" {
+		t.Errorf("packPassages() kept %d passages, want a.md and c.md", len(kept))
+	}
+	if len(dropped) != 1 || dropped[0].Path != "b.md" {
+		t.Errorf("packPassages() dropped %d passages, want b.md", len(dropped))
+	}
+
+	// 第一个段落都放不下时截断
+	kept, dropped = packPassages(passages[1:2], 20, countTokens)
+	if len(kept) != 1 || len(dropped) != 0 || countTokens(formatPassage(1, kept[0])) > 20 {
+		t.Errorf("packPassages() should truncate the first passage, kept=%d dropped=%d", len(kept), len(dropped))
+	}
+	if passages[1].Text != strings.Repeat("word ", 50) {
+		t.Error("packPassages() must not modify its input")
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// builtinPrompts 内置的prompt模板。<name>.tmpl 为中文模板，
+// <name>.<lang>.tmpl 为其他语言的模板，partials.tmpl 定义公共的子模板。
+//
+//go:embed prompts/*.tmpl
+var builtinPrompts embed.FS
+
+// PromptData 传给prompt模板的数据
+type PromptData struct {
+	Question   string
+	QueryType  string
+	Language   string // 问题的语言代码，如 "zh"、"en"
+	Collection string
+	Passages   []PromptPassage
+
+	// AnswerLanguage 回答使用的语言代码，默认与问题相同
+	AnswerLanguage string
+	// AnswerLanguageName 回答语言的名称，如 "中文"、"English"
+	AnswerLanguageName string
+	// OtherLanguages 为与回答语言不同的文档段落语言
+	OtherLanguages []string
+}
+
+// promptOptions 构建prompt时的选项
+type promptOptions struct {
+	Collection string // 使用该collection的模板覆盖
+	Lang       string // 回答语言，为空时使用问题的语言
+	Window     int    // 每个命中片段扩展的相邻片段数
+	Reserved   int    // prompt之外需要预留的token数（如对话历史）
+}
+
+// languageNames 语言代码对应的名称
+var languageNames = map[string]string{
+	"zh": "中文",
+	"en": "English",
+	"ja": "日本語",
+	"ko": "한국어",
+}
+
+// languageName 返回语言的名称，未知的语言代码原样返回
+func languageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
+
+// PromptPassage 模板中的一个文档段落
+type PromptPassage struct {
+	N         int    // 引用编号，从1开始
+	Source    string // 路径、标题和行号
+	Path      string
+	Title     string
+	Heading   string
+	StartLine int
+	EndLine   int
+	Lang      string
+	Metadata  map[string]string
+	Text      string
+}
+
+// newPromptData 构建模板数据
+func newPromptData(question string, queryType QueryTypeConfig, opts promptOptions, passages []*Passage) PromptData {
+	data := PromptData{
+		Question:       question,
+		QueryType:      queryType.Name,
+		Language:       detectLanguage(question),
+		Collection:     opts.Collection,
+		AnswerLanguage: opts.Lang,
+	}
+	if data.AnswerLanguage == "" {
+		data.AnswerLanguage = data.Language
+	}
+	if data.AnswerLanguage == "" {
+		// 问题中没有文字时使用中文，与内置的默认模板一致
+		data.AnswerLanguage = "zh"
+	}
+	data.AnswerLanguageName = languageName(data.AnswerLanguage)
+	seen := make(map[string]bool)
+	for i, p := range passages {
+		if p.Lang != "" && p.Lang != data.AnswerLanguage && !seen[p.Lang] {
+			seen[p.Lang] = true
+			data.OtherLanguages = append(data.OtherLanguages, languageName(p.Lang))
+		}
+		data.Passages = append(data.Passages, PromptPassage{
+			N:         i + 1,
+			Source:    sourceLabel(p),
+			Path:      p.Path,
+			Title:     p.Title,
+			Heading:   p.Heading,
+			StartLine: p.StartLine,
+			EndLine:   p.EndLine,
+			Lang:      p.Lang,
+			Metadata:  p.Metadata,
+			Text:      p.Text,
+		})
+	}
+	return data
+}
+
+// promptTemplate 查找查询类型对应的模板，返回模板内容及其来源。
+// 依次尝试该类型的模板和通用模板；对每个模板名，依次在collection目录、
+// prompts目录和内置模板中查找，每处都先找语言专用的版本（<name>.<lang>.tmpl）。
+func promptTemplate(cfg PromptsConfig, queryType QueryTypeConfig, collection, lang string) (string, string, error) {
+	name := queryType.Prompt
+	if name == "" {
+		name = queryType.Name
+	}
+	var dirs []string
+	if cfg.Dir != "" {
+		if collection != "" {
+			dirs = append(dirs, filepath.Join(cfg.Dir, collection))
+		}
+		dirs = append(dirs, cfg.Dir)
+	}
+	for _, n := range []string{name, "general"} {
+		files := []string{n + ".tmpl"}
+		if lang != "" {
+			files = []string{n + "." + lang + ".tmpl", n + ".tmpl"}
+		}
+		for _, dir := range dirs {
+			for _, file := range files {
+				path := filepath.Join(dir, file)
+				text, err := os.ReadFile(path)
+				if err == nil {
+					return string(text), path, nil
+				}
+				if !os.IsNotExist(err) {
+					return "", "", err
+				}
+			}
+		}
+		for _, file := range files {
+			if text, err := builtinPrompts.ReadFile("prompts/" + file); err == nil {
+				return string(text), "builtin:" + file, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("no prompt template for query type %q", queryType.Name)
+}
+
+// renderPrompt 使用查询类型对应的模板渲染prompt，同时返回模板来源
+func renderPrompt(cfg PromptsConfig, queryType QueryTypeConfig, data PromptData) (string, string, error) {
+	text, source, err := promptTemplate(cfg, queryType, data.Collection, data.AnswerLanguage)
+	if err != nil {
+		return "", "", err
+	}
+	t := template.New("prompt")
+	if _, err := t.ParseFS(builtinPrompts, "prompts/partials.tmpl"); err != nil {
+		return "", "", err
+	}
+	if _, err := t.New(source).Parse(text); err != nil {
+		return "", "", fmt.Errorf("parsing prompt template %s: %w", source, err)
+	}
+	var b bytes.Buffer
+	if err := t.ExecuteTemplate(&b, source, data); err != nil {
+		return "", "", fmt.Errorf("rendering prompt template %s: %w", source, err)
+	}
+	return b.String(), source, nil
+}
+
+// answerPrompt 为问题构建的最终prompt
+type answerPrompt struct {
+	Prompt   string
+	Template string     // 模板来源
+	Passages []*Passage // 放入prompt的段落
+	Dropped  []*Passage // 超出预算被丢弃的段落
+	Tokens   int        // prompt的token数
+	Budget   int        // prompt可用的token数
+}
+
+// buildAnswerPrompt 扩展检索结果并在模型的token预算内渲染prompt
+func buildAnswerPrompt(ctx context.Context, r Retriever, question string, result *retrievalResult, opts promptOptions, countTokens func(string) int, cfg *Config) (*answerPrompt, error) {
+	// 上下文预算 = 模型上下文窗口 - 回答预留 - prompt模板和问题本身
+	modelCfg := cfg.Ollama.ChatModelConfig(cfg.Generator.Model)
+	available := modelCfg.NumCtx - modelCfg.AnswerTokens - opts.Reserved
+	empty, _, err := renderPrompt(cfg.Prompts, result.QueryType, newPromptData(question, result.QueryType, opts, nil))
+	if err != nil {
+		return nil, err
+	}
+	budget := available - countTokens(empty)
+	if budget <= 0 {
+		return nil, fmt.Errorf("no token budget left for passages: num_ctx %d - answer_tokens %d - reserved %d - template %d tokens = %d",
+			modelCfg.NumCtx, modelCfg.AnswerTokens, opts.Reserved, countTokens(empty), budget)
+	}
+	passages, err := expandPassages(ctx, r, result.Hits, opts.Window, budget, countTokens)
+	if err != nil {
+		return nil, fmt.Errorf("error expanding context: %v", err)
+	}
+	passages, dropped := packPassages(passages, budget, countTokens)
+
+	prompt, source, err := renderPrompt(cfg.Prompts, result.QueryType, newPromptData(question, result.QueryType, opts, passages))
+	if err != nil {
+		return nil, err
+	}
+	return &answerPrompt{
+		Prompt:   prompt,
+		Template: source,
+		Passages: passages,
+		Dropped:  dropped,
+		Tokens:   countTokens(prompt),
+		Budget:   available,
+	}, nil
+}
+
+// PromptCmd renders the final prompt for a question without calling the chat model.
+type PromptCmd struct {
+	Text   string `kong:"arg,required,help='Question to build the prompt for.'"`
+	Expand int    `help:"Number of neighbouring chunks to add before and after each selected chunk." default:"-1"`
+	Mode   string `help:"Retrieval mode: single, multi_query or hyde (defaults to retrieval.mode)." enum:",single,multi_query,hyde" default:""`
+	Lang   string `help:"Answer language (e.g. zh, en); detected from the question by default."`
+	// CrossLingual enables retrieval.cross_lingual for this question.
+	CrossLingual bool `help:"Also search the question translated into the other corpus languages."`
+
+	Filter SearchFilter `embed:""`
+
+	// Dependencies injected by tests; nil means the configured backends.
+	retriever   Retriever        `kong:"-"`
+	countTokens func(string) int `kong:"-"`
+	stdout      io.Writer        `kong:"-"`
+}
+
+// Run is the method called when the "prompt" command is executed.
+func (cmd *PromptCmd) Run(cli *CLI) error {
+	cfg := cli.LoadedConfig()
+	out := cmd.stdout
+	if out == nil {
+		out = os.Stdout
+	}
+	retriever := cmd.retriever
+	if retriever == nil {
+		client, err := cli.entClient()
+		if err != nil {
+			return fmt.Errorf("failed opening connection to postgres: %w", err)
+		}
+		retriever = &dbRetriever{client: client, cfg: cfg}
+	}
+	countTokens := cmd.countTokens
+	if countTokens == nil {
+		var err error
+		if countTokens, err = tokenCounter(cfg.App.TokenEncoding); err != nil {
+			return err
+		}
+	}
+
+	result, err := retriever.Search(context.Background(), cmd.Text, cmd.Filter, searchOptions{Mode: cmd.Mode, CrossLingual: cmd.CrossLingual})
+	if err != nil {
+		return fmt.Errorf("error searching documents: %v", err)
+	}
+	fmt.Fprintf(out, "📊 %s\n", result.Details)
+	// 与ask一致：没有相关片段时不会构建prompt，也不会调用模型
+	if len(result.Hits) == 0 {
+		fmt.Fprintln(out, noContextMessage)
+		return nil
+	}
+	window := cfg.Retrieval.NeighborWindow
+	if cmd.Expand >= 0 {
+		window = cmd.Expand
+	}
+	opts := promptOptions{Collection: cmd.Filter.Collection, Lang: cmd.Lang, Window: window}
+	prepared, err := buildAnswerPrompt(context.Background(), retriever, cmd.Text, result, opts, countTokens, cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "📝 模板: %s, %d 段, 约 %d/%d tokens\n", prepared.Template, len(prepared.Passages), prepared.Tokens, prepared.Budget)
+	if len(prepared.Dropped) > 0 {
+		fmt.Fprintf(out, "⚠️  超出token预算，丢弃了 %d 个段落\n", len(prepared.Dropped))
+	}
+	fmt.Fprintln(out, "─────────────────────────────")
+	fmt.Fprintln(out, prepared.Prompt)
+	return nil
+}
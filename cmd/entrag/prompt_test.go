@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderPromptBuiltin(t *testing.T) {
+	cfg := &Config{}
+	cfg.applyDefaults()
+	classifier := newQueryClassifier(cfg.Classifier, nil)
+	passages := []*Passage{
+		{Path: "data/cn/pdm.txt", Heading: "定义", StartLine: 3, EndLine: 9, Text: "产品数据管理（PDM）……"},
+	}
+
+	tests := []struct {
+		question, source, contains string
+	}{
+		{"什么是PDM？", "builtin:concept.tmpl", "重点解释概念的含义和特点"},
+		{"列举Ent ORM的优点", "builtin:list.tmpl", "编号列表"},
+		{"How to define schema edges?", "builtin:howto.en.tmpl", "Question: How to define schema edges?"},
+		{"hello", "builtin:general.en.tmpl", "Answer the question accurately"},
+	}
+	for _, tt := range tests {
+		qt := classifier.Classify(tt.question, nil)
+		prompt, source, err := renderPrompt(cfg.Prompts, qt, newPromptData(tt.question, qt, promptOptions{}, passages))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if source != tt.source {
+			t.Errorf("%q: template %s, want %s", tt.question, source, tt.source)
+		}
+		if !strings.Contains(prompt, tt.contains) {
+			t.Errorf("%q: prompt does not contain %q:\n%s", tt.question, tt.contains, prompt)
+		}
+		// 段落格式与packPassages计算预算时一致
+		if !strings.Contains(prompt, formatPassage(1, passages[0])) {
+			t.Errorf("%q: passage is not formatted as formatPassage:\n%s", tt.question, prompt)
+		}
+	}
+}
+
+func TestRenderPromptOverrides(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("concept.tmpl", "override {{.QueryType}}: {{.Question}}")
+	write("ent/concept.tmpl", `ent {{.Collection}} {{.Language}} {{range .Passages}}{{.N}}={{index .Metadata "version"}} {{end}}`)
+	write("general.tmpl", "general {{.Question}}")
+
+	cfg := PromptsConfig{Dir: dir}
+	concept := QueryTypeConfig{Name: "概念性", Prompt: "concept"}
+	passages := []*Passage{{Path: "data/ent/a.md", Metadata: map[string]string{"version": "v0.14"}}}
+
+	prompt, _, err := renderPrompt(cfg, concept, newPromptData("什么是PDM？", concept, promptOptions{}, passages))
+	if err != nil || prompt != "override 概念性: 什么是PDM？" {
+		t.Errorf("type override: %q (%v)", prompt, err)
+	}
+	prompt, _, err = renderPrompt(cfg, concept, newPromptData("What is ent?", concept, promptOptions{Collection: "ent"}, passages))
+	if err != nil || prompt != "ent ent en 1=v0.14 " {
+		t.Errorf("collection override: %q (%v)", prompt, err)
+	}
+	// 没有对应模板的自定义类型使用通用模板
+	custom := QueryTypeConfig{Name: "排错"}
+	prompt, source, err := renderPrompt(cfg, custom, newPromptData("为什么迁移失败？", custom, promptOptions{}, nil))
+	if err != nil || prompt != "general 为什么迁移失败？" || source != filepath.Join(dir, "general.tmpl") {
+		t.Errorf("general fallback: %q from %s (%v)", prompt, source, err)
+	}
+}
+
+func TestRenderPromptAnswerLanguage(t *testing.T) {
+	cfg := &Config{}
+	cfg.applyDefaults()
+	concept := QueryTypeConfig{Name: "概念性", Prompt: "concept"}
+	passages := []*Passage{
+		{Path: "data/cn/pdm.txt", Lang: "zh", Text: "产品数据管理"},
+		{Path: "data/en/ent.md", Lang: "en", Text: "Ent is an entity framework"},
+	}
+
+	tests := []struct {
+		question, lang, source string
+		contains               []string
+	}{
+		{"什么是PDM？", "", "builtin:concept.tmpl", []string{"请使用中文回答。", "部分文档是English写的"}},
+		{"What is PDM?", "", "builtin:concept.en.tmpl", []string{"Answer in English.", "written in 中文"}},
+		// --lang覆盖问题的语言
+		{"什么是PDM？", "en", "builtin:concept.en.tmpl", []string{"Question: 什么是PDM？", "Answer in English."}},
+		{"What is PDM?", "ja", "builtin:concept.tmpl", []string{"请使用日本語回答。", "中文、English"}},
+	}
+	for _, tt := range tests {
+		data := newPromptData(tt.question, concept, promptOptions{Lang: tt.lang}, passages)
+		prompt, source, err := renderPrompt(cfg.Prompts, concept, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if source != tt.source {
+			t.Errorf("%q (%s): template %s, want %s", tt.question, tt.lang, source, tt.source)
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(prompt, s) {
+				t.Errorf("%q (%s): prompt does not contain %q:\n%s", tt.question, tt.lang, s, prompt)
+			}
+		}
+	}
+}
+
+func TestPromptCmdNoHits(t *testing.T) {
+	ask, _, _, cli := newAskTest()
+	retriever := ask.retriever.(*staticRetriever)
+	var out bytes.Buffer
+	cmd := &PromptCmd{Text: "What is Ent?", Expand: -1, retriever: retriever, countTokens: ask.countTokens, stdout: &out}
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Question: What is Ent?") {
+		t.Errorf("expected the rendered prompt:\n%s", out.String())
+	}
+
+	// 没有命中时与ask一样提示文档库未涵盖该问题，不输出prompt
+	retriever.result.Hits = nil
+	out.Reset()
+	if err := cmd.Run(cli); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, noContextMessage) || strings.Contains(got, "Question:") {
+		t.Errorf("expected the no-content message instead of a prompt:\n%s", got)
+	}
+}
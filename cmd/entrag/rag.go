@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"crypto/md5"
@@ -11,15 +10,17 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"entgo.io/ent/dialect/sql"
-	"github.com/charmbracelet/glamour"
 	"github.com/pgvector/pgvector-go"
 	"github.com/pkoukk/tiktoken-go"
 	"github.com/rotemtam/entrag/ent"
@@ -233,12 +234,36 @@ func getCacheKey(text string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// qaCacheKey 由后端、模型、请求参数和prompt生成问答缓存键；
+// json.Marshal按键名排序输出map，相同的参数总是得到相同的键。
+func qaCacheKey(backend, model, prompt string, options map[string]any) string {
+	opts, _ := json.Marshal(options)
+	return getCacheKey(backend + "\n" + model + "\n" + string(opts) + "\n" + prompt)
+}
+
+// formatOptions 以 key=value 的形式输出生成参数，按键名排序
+func formatOptions(options map[string]any) string {
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, options[k])
+	}
+	return strings.Join(parts, " ")
+}
+
 // These constants can be overridden by config
 var (
 	defaultTokenEncoding = "cl100k_base"
 	defaultChunkSize     = 1000
 )
 
+// noContextMessage 没有足够相关的片段时ask和prompt输出的提示
+const noContextMessage = "📭 文档库中没有与该问题相关的内容，无法基于文档回答。"
+
 // Ollama API structures
 type OllamaEmbedRequest struct {
 	Model  string `json:"model"`
@@ -250,14 +275,16 @@ type OllamaEmbedResponse struct {
 }
 
 type OllamaChatRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Options map[string]any `json:"options,omitempty"`
 }
 
 type OllamaChatResponse struct {
 	Response string `json:"response"`
 	Done     bool   `json:"done"`
+	Error    string `json:"error,omitempty"`
 }
 
 type (
@@ -267,11 +294,51 @@ type (
 	}
 	// IndexCmd creates the embedding index on the database.
 	IndexCmd struct {
+		RebuildText bool `help:"Recompute the full-text search vectors of all chunks."`
 	}
 	// AskCmd is another leaf command.
 	AskCmd struct {
 		// Text is the positional argument for the ask command.
 		Text string `kong:"arg,required,help='Text for the ask command.'"`
+		// Expand overrides retrieval.neighbor_window when not negative.
+		Expand int `help:"Number of neighbouring chunks to add before and after each selected chunk." default:"-1"`
+		// Mode overrides retrieval.mode when set.
+		Mode    string `help:"Retrieval mode: single, multi_query or hyde (defaults to retrieval.mode)." enum:",single,multi_query,hyde" default:""`
+		Verbose bool   `short:"v" help:"Show retrieval details such as generated query variants."`
+		// Lang overrides the answer language detected from the question.
+		Lang string `help:"Answer language (e.g. zh, en); detected from the question by default."`
+		// CrossLingual enables retrieval.cross_lingual for this question.
+		CrossLingual bool `help:"Also search the question translated into the other corpus languages."`
+		// Agent searches sub-questions and model-requested follow-ups and answers from the union.
+		Agent bool `help:"Split the question into sub-questions, search each and let the model request more searches."`
+
+		Filter SearchFilter `embed:""`
+		// Generation overrides ollama.options for this question.
+		Generation GenerationOptions `embed:""`
+		// Extractive skips the chat model; it is also the fallback when generation fails.
+		Extractive bool `help:"Answer with the best matching sentences of the passages instead of the chat model."`
+		// Verify enables grounding for this question.
+		Verify bool `help:"Check each answer sentence against the passages and flag unsupported ones."`
+		// Format json prints a single JSON document to stdout and progress to stderr.
+		Format string `help:"Output format: text or json." enum:"text,json" default:"text"`
+
+		// Dependencies injected by tests; nil means the configured backends.
+		retriever   Retriever        `kong:"-"`
+		generator   Generator        `kong:"-"`
+		planner     agentPlanner     `kong:"-"`
+		countTokens func(string) int `kong:"-"`
+		stdout      io.Writer        `kong:"-"`
+		stderr      io.Writer        `kong:"-"`
+	}
+	// SearchCmd runs retrieval only and prints the matching chunks.
+	SearchCmd struct {
+		Text    string `kong:"arg,required,help='Text to search for.'"`
+		Mode    string `help:"Retrieval mode: single, multi_query or hyde (defaults to retrieval.mode)." enum:",single,multi_query,hyde" default:""`
+		Verbose bool   `short:"v" help:"Show retrieval details such as generated query variants."`
+		// CrossLingual enables retrieval.cross_lingual for this search.
+		CrossLingual bool `help:"Also search the question translated into the other corpus languages."`
+
+		Filter SearchFilter `embed:""`
 	}
 	// StatsCmd shows statistics about chunks and embeddings.
 	StatsCmd struct {
@@ -295,14 +362,25 @@ func (cmd *LoadCmd) Run(ctx *CLI) error {
 	return filepath.WalkDir(ctx.Load.Path, func(path string, d fs.DirEntry, err error) error {
 		if filepath.Ext(path) == ".mdx" || filepath.Ext(path) == ".md" || filepath.Ext(path) == ".txt" {
 			log.Printf("Chunking %v", path)
+			info, err := readDocumentInfo(path)
+			if err != nil {
+				return err
+			}
 			chunks := breakToChunks(path, cfg.App.ChunkSize, cfg.App.TokenEncoding, cfg.App.ChunkOverlap, cfg.App.MinChunkSize)
 
 			for i, chunk := range chunks {
-				tokTotal += len(chunk)
+				tokTotal += len(chunk.Text)
 				client.Chunk.Create().
-					SetData(chunk).
+					SetData(chunk.Text).
 					SetPath(path).
 					SetNchunk(i).
+					SetTsv(searchVector(chunk.Text)).
+					SetTitle(info.Title).
+					SetLang(detectLanguage(chunk.Text)).
+					SetHeading(chunk.Heading).
+					SetStartLine(chunk.StartLine).
+					SetEndLine(chunk.EndLine).
+					SetMetadata(info.Metadata).
 					SaveX(context.Background())
 			}
 		}
@@ -318,6 +396,19 @@ func (cmd *IndexCmd) Run(cli *CLI) error {
 		return fmt.Errorf("failed opening connection to postgres: %w", err)
 	}
 	ctx := context.Background()
+
+	// 为旧数据补齐全文检索向量
+	if n, err := backfillSearchVectors(ctx, client, cmd.RebuildText); err != nil {
+		return fmt.Errorf("error computing full-text vectors: %v", err)
+	} else if n > 0 {
+		fmt.Printf("📝 已为 %d 个chunk计算全文检索向量\n", n)
+	}
+	if n, err := backfillLanguages(ctx, client); err != nil {
+		return fmt.Errorf("error detecting chunk languages: %v", err)
+	} else if n > 0 {
+		fmt.Printf("🌐 已为 %d 个chunk检测语言\n", n)
+	}
+
 	chunks := client.Chunk.Query().
 		Where(
 			chunk.Not(
@@ -395,257 +486,592 @@ func (cmd *AskCmd) Run(ctx *CLI) error {
 	totalStart := time.Now()
 
 	cfg := ctx.LoadedConfig()
-	client, err := ctx.entClient()
-	if err != nil {
-		return fmt.Errorf("failed opening connection to postgres: %w", err)
+	cfg.Ollama.Options = cfg.Ollama.Options.Merge(cmd.Generation)
+	out := cmd.stdout
+	if out == nil {
+		out = os.Stdout
+	}
+	// JSON模式下过程信息输出到stderr，stdout只输出最后的JSON文档
+	jsonOut := cmd.Format == "json"
+	var stdout io.Writer
+	if jsonOut {
+		stdout, out = out, cmd.stderr
+		if out == nil {
+			out = os.Stderr
+		}
+		defer func(old io.Writer) { progressOut = old }(progressOut)
+		progressOut = out
+	}
+	generator := cmd.generator
+	if generator == nil {
+		var err error
+		if generator, err = newGenerator(cfg); err != nil {
+			return err
+		}
+	}
+	retriever := cmd.retriever
+	if retriever == nil {
+		client, err := ctx.entClient()
+		if err != nil {
+			return fmt.Errorf("failed opening connection to postgres: %w", err)
+		}
+		retriever = &dbRetriever{client: client, cfg: cfg, generator: generator}
 	}
 
 	question := cmd.Text
-	fmt.Printf("🔍 处理问题: %s\n\n", question)
+	fmt.Fprintf(out, "🔍 处理问题: %s\n", question)
+	if !cmd.Filter.Empty() {
+		fmt.Fprintf(out, "🔎 过滤条件: %s\n", cmd.Filter)
+	}
+	fmt.Fprintln(out)
 
-	// 1. 获取问题的向量表示
-	fmt.Print("⏳ 正在生成问题向量...")
-	embeddingStart := time.Now()
-	emb, err := getEmbedding(question, cfg.Ollama.URL, cfg.Ollama.EmbedModel)
+	// 1-2. 生成问题向量并智能检索相似文档
+	fmt.Fprint(out, "⏳ 正在搜索相关文档...")
+	searchStart := time.Now()
+	searchOpts := searchOptions{Mode: cmd.Mode, CrossLingual: cmd.CrossLingual}
+	var result *retrievalResult
+	var trace *agentTrace
+	var err error
+	if cmd.Agent {
+		// 代理模式：分别检索各子问题，从所有结果的并集生成回答
+		planner := cmd.planner
+		if planner == nil {
+			if planner, err = newOllamaPlanner(cfg, generator); err != nil {
+				return err
+			}
+		}
+		result, trace, err = runAgent(context.Background(), question, retriever, cmd.Filter, searchOpts, planner, cfg.Agent)
+	} else {
+		result, err = retriever.Search(context.Background(), question, cmd.Filter, searchOpts)
+	}
 	if err != nil {
-		return fmt.Errorf("error getting embedding: %v", err)
+		return fmt.Errorf("error searching documents: %v", err)
+	}
+	hits := result.Hits
+	embeddingTime := result.EmbedTime
+	searchTime := time.Since(searchStart) - embeddingTime
+	fmt.Fprintf(out, " 完成 (⏱️ %v, %s)\n", searchTime, result.Details)
+	if cmd.Verbose {
+		printVariants(out, result)
+		if trace != nil {
+			printAgentTrace(out, trace)
+		}
 	}
-	embeddingTime := time.Since(embeddingStart)
-	fmt.Printf(" 完成 (⏱️ %v)\n", embeddingTime)
 
-	// 2. 智能检索相似文档
-	fmt.Print("⏳ 正在搜索相关文档...")
-	searchStart := time.Now()
-	embs, searchDetails := performIntelligentSearch(client, emb, question, cfg)
-	searchTime := time.Since(searchStart)
-	fmt.Printf(" 完成 (⏱️ %v, %s)\n", searchTime, searchDetails)
+	report := &askOutput{
+		Question:      question,
+		QueryType:     result.QueryType.Name,
+		Citations:     []askCitation{},
+		RetrievalMode: result.Mode,
+		AgentTrace:    trace,
+		Models: askModels{
+			Generator: cfg.Generator.Type,
+			Chat:      generator.Model(),
+			Embedding: cfg.Ollama.EmbedModel,
+			Reranker:  result.Reranker,
+		},
+		Cache: askCache{Embedding: result.EmbedCache},
+	}
+	report.Timings.Embedding = milliseconds(embeddingTime)
+	report.Timings.Search = milliseconds(searchTime)
+	report.Timings.Expand = milliseconds(result.ExpandTime)
+	report.Timings.Recall = milliseconds(result.SearchTime)
+	report.Timings.Rerank = milliseconds(result.RerankTime)
+
+	// 没有足够相关的片段时不调用模型，避免模型凭空猜测
+	if len(hits) == 0 {
+		fmt.Fprintln(out, "\n"+noContextMessage)
+		if jsonOut {
+			report.NoContext = true
+			report.Timings.Total = milliseconds(time.Since(totalStart))
+			return writeJSON(stdout, report)
+		}
+		return nil
+	}
+	printHits(out, hits)
 
 	// 3. 构建上下文
-	fmt.Print("⏳ 正在构建上下文...")
+	fmt.Fprint(out, "⏳ 正在构建上下文...")
 	contextStart := time.Now()
-	b := strings.Builder{}
-	for _, e := range embs {
-		chnk := e.Edges.Chunk
-		b.WriteString(fmt.Sprintf("From file: %v\n", chnk.Path))
-		b.WriteString(chnk.Data)
-		b.WriteString("\n---\n")
+	window := cfg.Retrieval.NeighborWindow
+	if cmd.Expand >= 0 {
+		window = cmd.Expand
+	}
+	countTokens := cmd.countTokens
+	if countTokens == nil {
+		if countTokens, err = tokenCounter(cfg.App.TokenEncoding); err != nil {
+			return err
+		}
 	}
-
-	// 优化后的prompt模板
-	query := buildOptimizedPrompt(question, b.String())
+	opts := promptOptions{Collection: cmd.Filter.Collection, Lang: cmd.Lang, Window: window}
+	prepared, err := buildAnswerPrompt(context.Background(), retriever, question, result, opts, countTokens, cfg)
+	if err != nil {
+		return err
+	}
+	query, passages, dropped := prepared.Prompt, prepared.Passages, prepared.Dropped
 	contextTime := time.Since(contextStart)
-	fmt.Printf(" 完成 (⏱️ %v)\n", contextTime)
+	fmt.Fprintf(out, " 完成 (⏱️ %v, %d 个片段合并为 %d 段, 约 %d/%d tokens, 模板: %s)\n",
+		contextTime, len(hits), len(passages), prepared.Tokens, prepared.Budget, prepared.Template)
+	if len(dropped) > 0 {
+		fmt.Fprintf(out, "   ⚠️  上下文超出 %s 的token预算，丢弃了 %d 个段落:\n", generator.Model(), len(dropped))
+		for _, p := range dropped {
+			fmt.Fprintf(out, "      - %s #%d-%d\n", p.Path, p.FirstChunk, p.LastChunk)
+		}
+	}
 
-	// 4. 生成回答
-	fmt.Print("⏳ 正在生成回答...")
+	// 4. 流式生成回答，Ctrl-C取消生成；生成失败或超时时改用抽取式回答
+	var answer string
+	var cacheHit bool
+	var generationErr error
+	printer := newAnswerPrinter(out)
 	generationStart := time.Now()
-	answer, err := getChatCompletion(query, cfg.Ollama.URL, cfg.Ollama.ChatModel)
-	if err != nil {
-		return fmt.Errorf("error creating chat completion: %v", err)
+	if !cmd.Extractive {
+		fmt.Fprintln(out, "⏳ 正在生成回答...")
+		sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		genCtx := sigCtx
+		if cfg.Extractive.Timeout > 0 {
+			var cancel context.CancelFunc
+			genCtx, cancel = context.WithTimeout(sigCtx, time.Duration(cfg.Extractive.Timeout)*time.Second)
+			defer cancel()
+		}
+		if options := cfg.Ollama.Options.Map(); len(options) > 0 {
+			fmt.Fprintf(out, "   🎛️  生成参数: %s\n", formatOptions(options))
+		}
+		onToken := printer.Write
+		if jsonOut {
+			onToken = nil
+		}
+		answer, cacheHit, err = generateAnswer(genCtx, out, generator, query, cfg.Ollama.Options, onToken)
+		if err != nil {
+			if sigCtx.Err() != nil {
+				fmt.Fprintln(out, "\n⛔ 已取消生成，结果未缓存")
+				return fmt.Errorf("generation cancelled")
+			}
+			if genCtx.Err() != nil {
+				err = fmt.Errorf("timed out after %ds", cfg.Extractive.Timeout)
+			}
+			if !*cfg.Extractive.Fallback {
+				return fmt.Errorf("error creating chat completion: %v", err)
+			}
+			if err := printer.Finish(); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "\n⚠️  生成回答失败 (%v)，改用抽取式回答\n", err)
+			generationErr = err
+			printer = newAnswerPrinter(out)
+		}
+	}
+	extractive := cmd.Extractive || generationErr != nil
+	var extracts []extract
+	if extractive {
+		fmt.Fprintln(out, "⏳ 正在从文档中抽取回答...")
+		extracts = extractSentences(question, passages, cfg.Extractive, func(text string) ([]float32, error) {
+			return retriever.Embed(context.Background(), text)
+		})
+		answer = formatExtractiveAnswer(extracts, passages)
+		if !jsonOut {
+			printer.Write(answer)
+		}
 	}
 	generationTime := time.Since(generationStart)
-	fmt.Printf(" 完成 (⏱️ %v)\n", generationTime)
 
-	// 5. 渲染输出
-	fmt.Print("⏳ 正在渲染结果...")
+	// 5. 终端中将流式输出替换为渲染后的结果，go代码块中的语法错误标注在出错的行旁
+	codeChecks := checkCode(answer, passages)
+	annotated := annotateCode(answer, codeChecks)
 	renderStart := time.Now()
-	out, err := glamour.Render(answer, "dark")
-	if err != nil {
-		return fmt.Errorf("error rendering markdown: %v", err)
+	if err := printer.FinishWith(annotated, annotatedBlocks(annotated, codeChecks)); err != nil {
+		return err
 	}
 	renderTime := time.Since(renderStart)
-	fmt.Printf(" 完成 (⏱️ %v)\n\n", renderTime)
+	switch {
+	case extractive && len(extracts) == 0:
+		fmt.Fprintf(out, "\n📭 文档中没有与问题相关的句子 (⏱️ %v)\n\n", generationTime)
+	case extractive:
+		fmt.Fprintf(out, "\n✅ 抽取式回答完成 (⏱️ %v, %d 个句子, 未调用聊天模型，结果未缓存)\n\n", generationTime, len(extracts))
+	case cacheHit:
+		fmt.Fprintf(out, "\n✅ 回答完成 (⏱️ %v, 来自问答缓存)\n\n", generationTime)
+	default:
+		fmt.Fprintf(out, "\n✅ 回答完成 (⏱️ %v, 已缓存问答结果, 问答缓存大小: %d)\n\n", generationTime, qaCache.Size())
+	}
+
+	// 6. 来源列表与引用校验
+	check := checkCitations(answer, len(passages))
+	printSources(out, passages, check)
+	fmt.Fprintln(out)
+
+	// 7. 代码检查：go代码块的语法以及与文档中符号的拼写
+	if len(codeChecks) > 0 {
+		printCodeChecks(out, codeChecks)
+		fmt.Fprintln(out)
+	}
+
+	// 8. 事实核查：标出没有文档支持的句子
+	var grounding *groundingReport
+	var groundingCached bool
+	var groundingTime time.Duration
+	// 抽取式回答的句子本身就来自文档，无需核查
+	if (cmd.Verify || cfg.Grounding.Enabled) && !extractive {
+		groundingStart := time.Now()
+		zero := 0.0
+		judge := func(prompt string) (string, error) {
+			return generator.Generate(context.Background(), prompt, GenerationOptions{Temperature: &zero}, nil)
+		}
+		answerKey := generationCacheKey(generator, query, cfg.Ollama.Options)
+		grounding, groundingCached, err = cachedVerifyAnswer(answerKey, answer, passages, cfg.Grounding, judge)
+		groundingTime = time.Since(groundingStart)
+		if err != nil {
+			log.Printf("Warning: grounding check failed: %v", err)
+		} else {
+			printGrounding(out, grounding)
+			fmt.Fprintln(out)
+		}
+	}
 
 	// 计算总时间
 	totalTime := time.Since(totalStart)
 
+	if jsonOut {
+		report.Answer = answer
+		report.AnswerMode = answerGenerated
+		if extractive {
+			report.AnswerMode = answerExtractive
+			report.Extracts = extracts
+		}
+		if generationErr != nil {
+			report.GenerationError = generationErr.Error()
+		}
+		report.Citations = newAskCitations(passages, check)
+		report.InvalidCitations = check.Invalid
+		report.CodeChecks = codeChecks
+		report.Template = prepared.Template
+		report.Timings.Context = milliseconds(contextTime)
+		report.Timings.Generation = milliseconds(generationTime)
+		report.Timings.Total = milliseconds(totalTime)
+		report.Cache.Answer = cacheHit
+		if grounding != nil {
+			report.Groundedness = &grounding.Score
+			report.Grounding = grounding
+			report.Cache.Grounding = groundingCached
+			report.Timings.Grounding = milliseconds(groundingTime)
+		}
+		return writeJSON(stdout, report)
+	}
+
 	// 输出时间统计
-	fmt.Println("📊 执行时间统计:")
-	fmt.Printf("   问题向量化: %8v (%.1f%%)\n", embeddingTime, float64(embeddingTime)/float64(totalTime)*100)
-	fmt.Printf("   向量搜索:   %8v (%.1f%%)\n", searchTime, float64(searchTime)/float64(totalTime)*100)
-	fmt.Printf("   上下文构建: %8v (%.1f%%)\n", contextTime, float64(contextTime)/float64(totalTime)*100)
-	fmt.Printf("   回答生成:   %8v (%.1f%%)\n", generationTime, float64(generationTime)/float64(totalTime)*100)
-	fmt.Printf("   结果渲染:   %8v (%.1f%%)\n", renderTime, float64(renderTime)/float64(totalTime)*100)
-	fmt.Printf("   ─────────────────────────────\n")
-	fmt.Printf("   总计时间:   %8v (100.0%%)\n\n", totalTime)
-
-	// 输出回答
-	fmt.Println("💬 回答:")
-	fmt.Print(out)
+	fmt.Fprintln(out, "📊 执行时间统计:")
+	fmt.Fprintf(out, "   问题向量化: %8v (%.1f%%)\n", embeddingTime, float64(embeddingTime)/float64(totalTime)*100)
+	fmt.Fprintf(out, "   向量搜索:   %8v (%.1f%%)\n", searchTime, float64(searchTime)/float64(totalTime)*100)
+	if len(result.Variants) > 1 || result.Reranker != "" {
+		if len(result.Variants) > 1 {
+			fmt.Fprintf(out, "     ├ 查询扩展: %6v (%.1f%%, %s)\n", result.ExpandTime, float64(result.ExpandTime)/float64(totalTime)*100, result.Mode)
+		}
+		fmt.Fprintf(out, "     ├ 召回:   %8v (%.1f%%)\n", result.SearchTime, float64(result.SearchTime)/float64(totalTime)*100)
+		if result.Reranker != "" {
+			fmt.Fprintf(out, "     ├ 重排序: %8v (%.1f%%, %s)\n", result.RerankTime, float64(result.RerankTime)/float64(totalTime)*100, result.Reranker)
+		}
+	}
+	fmt.Fprintf(out, "   上下文构建: %8v (%.1f%%)\n", contextTime, float64(contextTime)/float64(totalTime)*100)
+	if extractive {
+		fmt.Fprintf(out, "   抽取回答:   %8v (%.1f%%)\n", generationTime, float64(generationTime)/float64(totalTime)*100)
+	} else {
+		fmt.Fprintf(out, "   回答生成:   %8v (%.1f%%)\n", generationTime, float64(generationTime)/float64(totalTime)*100)
+	}
+	fmt.Fprintf(out, "   结果渲染:   %8v (%.1f%%)\n", renderTime, float64(renderTime)/float64(totalTime)*100)
+	if grounding != nil {
+		fmt.Fprintf(out, "   事实核查:   %8v (%.1f%%)\n", groundingTime, float64(groundingTime)/float64(totalTime)*100)
+	}
+	fmt.Fprintf(out, "   ─────────────────────────────\n")
+	fmt.Fprintf(out, "   总计时间:   %8v (100.0%%)\n", totalTime)
+
+	return nil
+}
+
+// Run is the method called when the "search" command is executed.
+func (cmd *SearchCmd) Run(ctx *CLI) error {
+	cfg := ctx.LoadedConfig()
+	client, err := ctx.entClient()
+	if err != nil {
+		return fmt.Errorf("failed opening connection to postgres: %w", err)
+	}
 
+	fmt.Printf("🔍 搜索: %s\n", cmd.Text)
+	if !cmd.Filter.Empty() {
+		fmt.Printf("🔎 过滤条件: %s\n", cmd.Filter)
+	}
+
+	retriever := &dbRetriever{client: client, cfg: cfg}
+	result, err := retriever.Search(context.Background(), cmd.Text, cmd.Filter, searchOptions{Mode: cmd.Mode, CrossLingual: cmd.CrossLingual})
+	if err != nil {
+		return fmt.Errorf("error searching documents: %v", err)
+	}
+	fmt.Printf("📊 %s\n", result.Details)
+	if cmd.Verbose {
+		printVariants(os.Stdout, result)
+	}
+	fmt.Println()
+	if len(result.Hits) == 0 {
+		fmt.Println("📭 没有找到相关片段")
+		return nil
+	}
+	printHits(os.Stdout, result.Hits)
 	return nil
 }
 
+// retrievalResult 智能检索的结果
+type retrievalResult struct {
+	Hits       []*SearchHit    // 最终选中的片段
+	QueryType  QueryTypeConfig // 识别出的查询类型
+	Candidates int             // 候选片段数
+	Details    string          // 检索过程的可读描述
+
+	Mode       string         // 检索模式
+	Variants   []queryVariant // 参与检索的问题变体
+	Reranker   string         // 使用的重排序器，未启用时为空
+	EmbedTime  time.Duration  // 生成问题向量耗时
+	EmbedCache bool           // 问题向量来自缓存
+	ExpandTime time.Duration  // 生成问题变体耗时
+	SearchTime time.Duration  // 召回（向量+全文检索）耗时
+	RerankTime time.Duration  // 重排序耗时
+}
+
+// searchOptions 覆盖配置文件中检索设置的命令行选项
+type searchOptions struct {
+	Mode         string // 检索模式，为空时使用retrieval.mode
+	CrossLingual bool   // 同时检索问题的译文，与retrieval.cross_lingual.enabled取或
+}
+
 // 智能检索函数
-func performIntelligentSearch(client *ent.Client, emb []float32, question string, cfg *Config) ([]*ent.Embedding, string) {
-	embVec := pgvector.NewVector(emb)
+func performIntelligentSearch(client *ent.Client, classifier *QueryClassifier, generator Generator, emb []float32, question string, filter SearchFilter, opts searchOptions, cfg *Config) (*retrievalResult, error) {
+	ctx := context.Background()
+	mode := opts.Mode
+	if mode == "" {
+		mode = cfg.Retrieval.Mode
+	}
+	filter, err := filter.resolve(cfg.Collections)
+	if err != nil {
+		return nil, err
+	}
+	result := &retrievalResult{Mode: mode}
+
+	// 1. 查询类型分析
+	result.QueryType = classifier.Classify(question, emb)
+	k := result.QueryType.K
+	if k <= 0 {
+		k = cfg.App.MaxSimilarChunks
+	}
 
-	// 1. 扩大搜索范围，获取更多候选
-	searchLimit := cfg.App.MaxSimilarChunks * 3
+	// 2. 扩大搜索范围，获取更多候选（向量检索与全文检索并行，RRF融合）
+	searchLimit := k * 3
 	if searchLimit > 30 {
 		searchLimit = 30
 	}
 
-	candidateEmbs := client.Embedding.
-		Query().
-		Order(func(s *sql.Selector) {
-			s.OrderExpr(sql.ExprP("embedding <-> $1", embVec))
-		}).
-		WithChunk().
-		Limit(searchLimit).
-		AllX(context.Background())
+	// 多查询/HyDE模式下先由聊天模型生成问题变体，失败时只用原问题检索
+	expandStart := time.Now()
+	generate := func(prompt string) (string, error) {
+		return cachedGenerate(ctx, generator, prompt, GenerationOptions{})
+	}
+	variants, err := generateVariants(question, mode, cfg.Retrieval.Paraphrases, generate)
+	if err != nil {
+		if variants == nil {
+			return nil, err
+		}
+		log.Printf("Warning: generating %s query variants failed, searching the question only: %v", mode, err)
+	}
+	// 跨语言检索：把问题翻译成语料中的其他语言，与其他变体一起检索和融合
+	if crossLingual := cfg.Retrieval.CrossLingual; opts.CrossLingual || crossLingual.Enabled {
+		targets := translationTargets(question, crossLingual.Languages, filter.Lang)
+		translations, err := translateVariants(question, targets, crossLingual, generate)
+		if err != nil {
+			if translations == nil {
+				return nil, err
+			}
+			log.Printf("Warning: translating the question failed: %v", err)
+		}
+		variants = append(variants, translations...)
+	}
+	result.Variants = variants
+	result.ExpandTime = time.Since(expandStart)
 
-	// 2. 查询类型分析
-	queryType := classifyQuery(question)
+	searchStart := time.Now()
+	embed := func(text string) ([]float32, error) {
+		return getEmbedding(text, cfg.Ollama.URL, cfg.Ollama.EmbedModel)
+	}
+	candidates, err := searchVariants(ctx, variants, emb, embed, func(ctx context.Context, emb []float32, text string) ([]*SearchHit, error) {
+		return hybridSearch(ctx, client, emb, text, searchLimit, filter, cfg)
+	}, cfg.Retrieval.RRFK)
+	if err != nil {
+		return nil, err
+	}
+	result.SearchTime = time.Since(searchStart)
+	result.Candidates = len(candidates)
+	inRange := withinDistance(candidates, *cfg.Retrieval.MaxDistance)
 
-	// 3. 智能过滤和重排序
-	filteredEmbs := intelligentFilter(candidateEmbs, question, queryType, cfg)
+	// 3. 重排序：对前N个候选重新打分，失败时沿用融合排序
+	reranker, topN, err := newReranker(cfg, generator)
+	if err != nil {
+		return nil, err
+	}
+	var rerankDetails string
+	if reranker != nil {
+		rerankStart := time.Now()
+		reranked, err := rerank(ctx, reranker, question, inRange, topN)
+		result.RerankTime = time.Since(rerankStart)
+		result.Reranker = reranker.Name()
+		if err != nil {
+			log.Printf("Warning: %s reranker failed, keeping fused order: %v", reranker.Name(), err)
+		} else {
+			inRange = reranked
+			rerankDetails = fmt.Sprintf(", 经 %s 重排序保留前 %d 个", reranker.Name(), len(reranked))
+		}
+	}
 
-	// 4. 多样性优化
-	finalEmbs := optimizeForDiversity(filteredEmbs, cfg.App.MaxSimilarChunks)
+	// 4-5. 智能过滤与多样性优化
+	result.Hits = selectHits(inRange, question, result.QueryType, cfg)
 
-	details := fmt.Sprintf("从 %d 个候选中智能选择了 %d 个高质量片段 (查询类型: %s)",
-		len(candidateEmbs), len(finalEmbs), queryType)
+	result.Details = fmt.Sprintf("从 %d 个候选中智能选择了 %d 个高质量片段 (查询类型: %s)",
+		len(candidates), len(result.Hits), result.QueryType.Name)
+	if dropped := len(candidates) - len(withinDistance(candidates, *cfg.Retrieval.MaxDistance)); dropped > 0 {
+		result.Details += fmt.Sprintf(", %d 个片段距离超过 %.2f 被丢弃", dropped, *cfg.Retrieval.MaxDistance)
+	}
+	result.Details += rerankDetails
+	if len(variants) > 1 {
+		result.Details += fmt.Sprintf(", 融合了 %d 个问题变体", len(variants))
+	}
+	if n := countTranslations(variants); n > 0 {
+		result.Details += fmt.Sprintf(" (含 %d 个译文)", n)
+	}
 
-	return finalEmbs, details
+	return result, nil
 }
 
-// 查询类型分类
-func classifyQuery(question string) string {
-	question = strings.ToLower(question)
+// selectHits 对候选片段进行智能过滤和多样性优化。
+// 候选先按得分排序，因此相同的候选集合总是得到相同的结果。
+func selectHits(candidates []*SearchHit, question string, queryType QueryTypeConfig, cfg *Config) []*SearchHit {
+	// 相关性阈值：丢弃距离过远的片段
+	sorted := withinDistance(candidates, *cfg.Retrieval.MaxDistance)
+	sortHits(sorted)
 
-	// 概念性问题
-	if strings.Contains(question, "what is") || strings.Contains(question, "什么是") ||
-		strings.Contains(question, "定义") || strings.Contains(question, "概念") {
-		return "概念性"
-	}
+	// 智能过滤
+	filtered := intelligentFilter(sorted, question, queryType, cfg)
 
-	// 操作性问题
-	if strings.Contains(question, "how to") || strings.Contains(question, "如何") ||
-		strings.Contains(question, "怎样") || strings.Contains(question, "方法") {
-		return "操作性"
+	// 多样性优化
+	k := queryType.K
+	if k <= 0 {
+		k = cfg.App.MaxSimilarChunks
+	}
+	if cfg.Retrieval.Diversity == "mmr" {
+		return selectMMR(filtered, k, *cfg.Retrieval.MMRLambda)
 	}
+	return optimizeForDiversity(filtered, k)
+}
 
-	// 比较性问题
-	if strings.Contains(question, "difference") || strings.Contains(question, "区别") ||
-		strings.Contains(question, "比较") || strings.Contains(question, "对比") {
-		return "比较性"
+// withinDistance 返回距离不超过maxDistance的片段副本，maxDistance为0时不过滤。
+// 仅全文检索命中、尚无向量的片段（距离为+Inf）无法按距离判断，总是保留。
+func withinDistance(hits []*SearchHit, maxDistance float64) []*SearchHit {
+	var kept []*SearchHit
+	for _, h := range hits {
+		if maxDistance <= 0 || h.Distance <= maxDistance || math.IsInf(h.Distance, 1) {
+			kept = append(kept, h)
+		}
 	}
+	return kept
+}
 
-	// 列举性问题
-	if strings.Contains(question, "列举") || strings.Contains(question, "有哪些") ||
-		strings.Contains(question, "特点") || strings.Contains(question, "优点") {
-		return "列举性"
+// printHits 输出检索到的片段及其相似度
+func printHits(w io.Writer, hits []*SearchHit) {
+	for i, h := range hits {
+		if h.RerankScore != nil {
+			fmt.Fprintf(w, "   [%d] %s #%d (距离: %.4f, 得分: %.4f, 重排序得分: %.4f)\n", i+1, h.Chunk.Path, h.Chunk.Nchunk, h.Distance, h.Score, *h.RerankScore)
+			continue
+		}
+		fmt.Fprintf(w, "   [%d] %s #%d (距离: %.4f, 得分: %.4f)\n", i+1, h.Chunk.Path, h.Chunk.Nchunk, h.Distance, h.Score)
 	}
+}
 
-	return "通用"
+// printVariants 输出参与检索的问题变体及各自召回的片段数
+func printVariants(w io.Writer, result *retrievalResult) {
+	fmt.Fprintf(w, "   🔀 检索模式: %s\n", result.Mode)
+	for i, v := range result.Variants {
+		kind := v.Kind
+		if v.Lang != "" {
+			kind += "→" + v.Lang
+		}
+		fmt.Fprintf(w, "      [%d] %s (%d 个候选): %s\n", i+1, kind, v.Hits, strings.ReplaceAll(v.Text, "\n", " "))
+	}
 }
 
 // 智能过滤函数
-func intelligentFilter(candidateEmbs []*ent.Embedding, question string, queryType string, cfg *Config) []*ent.Embedding {
-	var filtered []*ent.Embedding
+func intelligentFilter(candidates []*SearchHit, question string, queryType QueryTypeConfig, cfg *Config) []*SearchHit {
+	var filtered []*SearchHit
 	fileChunkCount := make(map[string]int)
-	questionWords := strings.Fields(strings.ToLower(question))
+	questionWords := uniqueTokens(tokenize(question))
+
+	// 文件多样性控制
+	maxPerFile := queryType.MaxPerFile
+	if maxPerFile <= 0 {
+		maxPerFile = 4
+	}
 
-	for _, emb := range candidateEmbs {
-		chunk := emb.Edges.Chunk
+	for _, hit := range candidates {
+		chunk := hit.Chunk
 
 		// 1. 基本过滤：长度检查
 		if len(chunk.Data) < cfg.App.MinChunkSize {
 			continue
 		}
 
-		// 2. 文件多样性控制（放宽限制）
-		maxPerFile := 4
-		if queryType == "概念性" {
-			maxPerFile = 3 // 概念性问题需要更多样化的来源
-		} else if queryType == "操作性" {
-			maxPerFile = 5 // 操作性问题可能需要更多细节
-		}
-
+		// 2. 文件多样性控制
 		if fileChunkCount[chunk.Path] >= maxPerFile {
 			continue
 		}
 
 		// 3. 关键词匹配度检查（更宽松）
 		chunkText := strings.ToLower(chunk.Data)
-		keywordMatches := 0
-		for _, word := range questionWords {
-			if len(word) > 2 && strings.Contains(chunkText, word) {
-				keywordMatches++
-			}
-		}
+		keywordMatches := countKeywordMatches(questionWords, chunk.Data)
 
-		// 4. 根据查询类型调整过滤标准（降低门槛）
-		shouldInclude := false
-		switch queryType {
-		case "概念性":
-			// 概念性问题：降低要求，只要有部分匹配就包含
-			shouldInclude = keywordMatches >= 1 ||
-				strings.Contains(chunkText, "定义") ||
-				strings.Contains(chunkText, "是") ||
-				strings.Contains(chunkText, "describes") ||
-				strings.Contains(chunkText, "definition") ||
-				strings.Contains(chunkText, "ent") || // 放宽：包含核心关键词
-				strings.Contains(chunkText, "orm")
-		case "操作性":
-			// 操作性问题：包含方法相关内容
-			shouldInclude = keywordMatches >= 1 ||
-				strings.Contains(chunkText, "步骤") ||
-				strings.Contains(chunkText, "方法") ||
-				strings.Contains(chunkText, "how") ||
-				strings.Contains(chunkText, "step") ||
-				strings.Contains(chunkText, "func") || // 放宽：包含代码相关
-				strings.Contains(chunkText, "function")
-		case "比较性":
-			// 比较性问题：包含比较相关内容
-			shouldInclude = keywordMatches >= 1 ||
-				strings.Contains(chunkText, "vs") ||
-				strings.Contains(chunkText, "compared") ||
-				strings.Contains(chunkText, "difference") ||
-				strings.Contains(chunkText, "pdm") || // 放宽：包含具体术语
-				strings.Contains(chunkText, "plm")
-		case "列举性":
-			// 列举性问题：包含特点、优点等内容
-			shouldInclude = keywordMatches >= 1 ||
-				strings.Contains(chunkText, "特点") ||
-				strings.Contains(chunkText, "优点") ||
-				strings.Contains(chunkText, "advantages") ||
-				strings.Contains(chunkText, "features") ||
-				strings.Contains(chunkText, "ent") || // 放宽：包含核心关键词
-				strings.Contains(chunkText, "orm")
-		default:
-			// 通用查询：只要有任何关键词匹配就包含
-			shouldInclude = keywordMatches >= 1 || len(questionWords) == 0
+		// 4. 根据查询类型的过滤关键词调整过滤标准
+		shouldInclude := keywordMatches >= 1 || len(questionWords) == 0
+		for _, kw := range queryType.Keywords {
+			if shouldInclude {
+				break
+			}
+			shouldInclude = strings.Contains(chunkText, strings.ToLower(kw))
 		}
 
 		// 5. 兜底策略：如果过滤太严格，降低标准
 		if !shouldInclude && len(filtered) < cfg.App.MaxSimilarChunks/2 {
 			// 如果当前结果太少，进一步放宽条件
 			for _, word := range questionWords {
-				if len(word) > 1 && strings.Contains(chunkText, word) {
+				if strings.Contains(chunkText, word) {
 					shouldInclude = true
 					break
 				}
 			}
 		}
 
+		// 全文检索命中的片段已包含问题中的词
+		if hit.TextRank > 0 {
+			shouldInclude = true
+		}
+
 		if shouldInclude {
-			filtered = append(filtered, emb)
+			filtered = append(filtered, hit)
 			fileChunkCount[chunk.Path]++
 		}
 	}
 
 	// 6. 最终兜底：如果还是没有结果，选择前几个相似度最高的
-	if len(filtered) == 0 && len(candidateEmbs) > 0 {
+	if len(filtered) == 0 && len(candidates) > 0 {
 		maxFallback := cfg.App.MaxSimilarChunks
-		if maxFallback > len(candidateEmbs) {
-			maxFallback = len(candidateEmbs)
+		if maxFallback > len(candidates) {
+			maxFallback = len(candidates)
 		}
 		for i := 0; i < maxFallback; i++ {
-			chunk := candidateEmbs[i].Edges.Chunk
+			chunk := candidates[i].Chunk
 			if len(chunk.Data) >= cfg.App.MinChunkSize {
-				filtered = append(filtered, candidateEmbs[i])
+				filtered = append(filtered, candidates[i])
 			}
 		}
 	}
@@ -653,32 +1079,37 @@ func intelligentFilter(candidateEmbs []*ent.Embedding, question string, queryTyp
 	return filtered
 }
 
-// 多样性优化函数
-func optimizeForDiversity(embs []*ent.Embedding, maxResults int) []*ent.Embedding {
-	if len(embs) <= maxResults {
-		return embs
+// 多样性优化函数：按文件轮询选择，结果按得分排序
+func optimizeForDiversity(hits []*SearchHit, maxResults int) []*SearchHit {
+	if len(hits) <= maxResults {
+		return hits
 	}
 
-	// 按文件路径分组
-	fileGroups := make(map[string][]*ent.Embedding)
-	for _, emb := range embs {
-		path := emb.Edges.Chunk.Path
-		fileGroups[path] = append(fileGroups[path], emb)
+	// 按文件路径分组，文件按其最高分片段的顺序排列
+	var paths []string
+	fileGroups := make(map[string][]*SearchHit)
+	for _, hit := range hits {
+		path := hit.Chunk.Path
+		if _, ok := fileGroups[path]; !ok {
+			paths = append(paths, path)
+		}
+		fileGroups[path] = append(fileGroups[path], hit)
 	}
 
 	// 优化选择策略：尽量从不同文件选择
-	var result []*ent.Embedding
+	var result []*SearchHit
 	fileIndex := make(map[string]int)
 
-	for len(result) < maxResults && len(result) < len(embs) {
+	for len(result) < maxResults && len(result) < len(hits) {
 		added := false
 
 		// 轮询各个文件，每轮最多从每个文件选择1个
-		for path, group := range fileGroups {
+		for _, path := range paths {
 			if len(result) >= maxResults {
 				break
 			}
 
+			group := fileGroups[path]
 			idx := fileIndex[path]
 			if idx < len(group) {
 				result = append(result, group[idx])
@@ -692,55 +1123,24 @@ func optimizeForDiversity(embs []*ent.Embedding, maxResults int) []*ent.Embeddin
 		}
 	}
 
+	sortHits(result)
 	return result
 }
 
-// 优化后的prompt构建
-func buildOptimizedPrompt(question string, context string) string {
-	// 根据问题类型构建更好的prompt
-	queryType := classifyQuery(question)
-
-	var promptTemplate string
-	switch queryType {
-	case "概念性":
-		promptTemplate = `基于以下技术文档，请准确回答关于概念的问题。请提供清晰的定义和解释。
-
-技术文档:
-%s
-
-问题: %s
-
-请提供准确、简洁的回答，重点解释概念的含义和特点。`
-	case "操作性":
-		promptTemplate = `基于以下技术文档，请详细回答关于操作方法的问题。请提供具体的步骤和示例。
-
-技术文档:
-%s
-
-问题: %s
-
-请提供详细的操作步骤，包括必要的代码示例和注意事项。`
-	case "比较性":
-		promptTemplate = `基于以下技术文档，请详细比较和分析。请突出不同点和相似点。
-
-技术文档:
-%s
-
-问题: %s
-
-请提供详细的比较分析，突出关键差异和各自的优缺点。`
-	default:
-		promptTemplate = `基于以下技术文档，请准确回答问题。
-
-技术文档:
-%s
-
-问题: %s
+// formatPassage 格式化第n个段落
+func formatPassage(n int, p *Passage) string {
+	return fmt.Sprintf("[%d] From file: %s\n%s\n---\n", n, sourceLabel(p), p.Text)
+}
 
-请基于文档内容提供准确、详细的回答。`
+// tokenCounter 返回使用指定编码计算token数的函数
+func tokenCounter(encoding string) (func(string) int, error) {
+	tke, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("error getting token encoding: %v", err)
 	}
-
-	return fmt.Sprintf(promptTemplate, context, question)
+	return func(s string) int {
+		return len(tke.Encode(s, nil, nil))
+	}, nil
 }
 
 // Run is the method called when the "stats" command is executed.
@@ -1021,13 +1421,20 @@ func (c *CLI) entClient() (*ent.Client, error) {
 	return ent.Open("postgres", cfg.Database.URL)
 }
 
+// textChunk 文档切分出的片段及其在原文中的位置
+type textChunk struct {
+	Text      string
+	StartLine int    // 起始行（从1开始）
+	EndLine   int    // 结束行
+	Heading   string // 片段开始处所在的Markdown标题
+}
+
 // breakToChunks reads the file in `path` and breaks it into chunks with overlap
-func breakToChunks(path string, chunkSize int, tokenEncoding string, overlap int, minChunkSize int) []string {
-	f, err := os.Open(path)
+func breakToChunks(path string, chunkSize int, tokenEncoding string, overlap int, minChunkSize int) []textChunk {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		log.Fatalf("Error opening file: %v", err)
 	}
-	defer f.Close()
 
 	tke, err := tiktoken.GetEncoding(tokenEncoding)
 	if err != nil {
@@ -1035,88 +1442,89 @@ func breakToChunks(path string, chunkSize int, tokenEncoding string, overlap int
 	}
 
 	// 读取所有段落
-	var paragraphs []string
-	scanner := bufio.NewScanner(f)
-	scanner.Split(splitByParagraph)
-
-	for scanner.Scan() {
-		paragraphs = append(paragraphs, scanner.Text())
-	}
-
+	paragraphs := splitParagraphs(string(data))
 	if len(paragraphs) == 0 {
-		return []string{}
+		return []textChunk{}
 	}
+	headings := markdownHeadings(string(data))
 
-	var chunks []string
-	currentChunk := ""
+	var chunks []textChunk
+	current := textChunk{}
 	overlapBuffer := "" // 用于存储重叠内容
+	overlapLine := 0    // 重叠内容的起始行
 
-	for _, paragraph := range paragraphs {
-		testChunk := currentChunk + paragraph + "\n"
+	// parts 记录当前chunk中每个段落的起始字节位置，用于确定重叠内容的起始行
+	type part struct {
+		offset int
+		para   paragraph
+	}
+	var parts []part
+	add := func(p paragraph) {
+		if current.StartLine == 0 {
+			current.StartLine = p.StartLine
+		}
+		current.EndLine = p.EndLine
+		parts = append(parts, part{len(current.Text), p})
+		current.Text += p.Text + "\n"
+	}
+	// lineAt 返回当前chunk中字节位置offset所在的行
+	lineAt := func(offset int) int {
+		for i := len(parts) - 1; i >= 0; i-- {
+			if parts[i].offset <= offset {
+				return parts[i].para.StartLine + strings.Count(current.Text[parts[i].offset:offset], "\n")
+			}
+		}
+		return current.StartLine
+	}
+	save := func() {
+		current.Heading = headings[current.StartLine]
+		chunks = append(chunks, current)
+	}
+
+	for _, p := range paragraphs {
+		testChunk := current.Text + p.Text + "\n"
 		toks := tke.Encode(testChunk, nil, nil)
 
-		if len(toks) > chunkSize && currentChunk != "" {
+		if len(toks) > chunkSize && current.Text != "" {
 			// 当前chunk已满，保存并开始新chunk
-			if len(currentChunk) >= minChunkSize {
-				chunks = append(chunks, currentChunk)
+			if len(current.Text) >= minChunkSize {
+				save()
 
 				// 创建重叠内容
 				if overlap > 0 {
-					overlapTokens := tke.Encode(currentChunk, nil, nil)
+					overlapTokens := tke.Encode(current.Text, nil, nil)
+					overlapBuffer, overlapLine = current.Text, current.StartLine
 					if len(overlapTokens) > overlap {
-						// 从当前chunk的末尾提取重叠内容
-						overlapText := currentChunk
-						overlapToks := tke.Encode(overlapText, nil, nil)
-
-						// 找到重叠部分的起始位置
-						if len(overlapToks) > overlap {
-							// 简单实现：取最后overlap个tokens对应的文本
-							overlapStart := len(overlapText) - (overlap * 4) // 粗略估计
-							if overlapStart > 0 {
-								overlapBuffer = overlapText[overlapStart:]
-							} else {
-								overlapBuffer = overlapText
-							}
-						} else {
-							overlapBuffer = overlapText
+						// 简单实现：取最后overlap个tokens对应的文本
+						overlapStart := len(current.Text) - (overlap * 4) // 粗略估计
+						if overlapStart > 0 {
+							overlapBuffer, overlapLine = current.Text[overlapStart:], lineAt(overlapStart)
 						}
-					} else {
-						overlapBuffer = currentChunk
 					}
 				}
 			}
 
 			// 开始新chunk，包含重叠内容
-			currentChunk = overlapBuffer + paragraph + "\n"
-			overlapBuffer = ""
+			current, parts = textChunk{Text: overlapBuffer, StartLine: overlapLine, EndLine: overlapLine}, nil
+			if overlapBuffer != "" {
+				parts = []part{{0, paragraph{Text: overlapBuffer, StartLine: overlapLine}}}
+			}
+			overlapBuffer, overlapLine = "", 0
+			add(p)
 		} else {
 			// 继续添加到当前chunk
-			currentChunk = testChunk
+			add(p)
 		}
 	}
 
 	// 添加最后一个chunk
-	if currentChunk != "" && len(currentChunk) >= minChunkSize {
-		chunks = append(chunks, currentChunk)
+	if current.Text != "" && len(current.Text) >= minChunkSize {
+		save()
 	}
 
 	return chunks
 }
 
-// splitByParagraph is a custom split function for bufio.Scanner to split by
-// paragraphs (text pieces separated by two newlines).
-func splitByParagraph(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
-		return i + 2, bytes.TrimSpace(data[:i]), nil
-	}
-
-	if atEOF && len(data) != 0 {
-		return len(data), bytes.TrimSpace(data), nil
-	}
-
-	return 0, nil, nil
-}
-
 // getEmbedding invokes the Ollama embedding API to calculate the embedding
 // for the given string. It returns the embedding.
 func getEmbedding(data string, ollamaURL string, model string) ([]float32, error) {
@@ -1124,12 +1532,26 @@ func getEmbedding(data string, ollamaURL string, model string) ([]float32, error
 
 	// 尝试从缓存获取
 	if cachedEmbedding, found := embeddingCache.Get(cacheKey); found {
-		fmt.Printf("   💾 使用缓存 (缓存大小: %d)\n", embeddingCache.Size())
+		fmt.Fprintf(progressOut, "   💾 使用缓存 (缓存大小: %d)\n", embeddingCache.Size())
 		return cachedEmbedding, nil
 	}
 
-	fmt.Printf("   🔄 未找到缓存，调用API (缓存大小: %d)\n", embeddingCache.Size())
+	fmt.Fprintf(progressOut, "   🔄 未找到缓存，调用API (缓存大小: %d)\n", embeddingCache.Size())
+
+	embedding, err := requestEmbedding(data, ollamaURL, model)
+	if err != nil {
+		return nil, err
+	}
+
+	// 将结果缓存
+	embeddingCache.Set(cacheKey, embedding)
+	fmt.Fprintf(progressOut, "   💾 已缓存结果 (缓存大小: %d)\n", embeddingCache.Size())
 
+	return embedding, nil
+}
+
+// requestEmbedding 调用Ollama向量接口，不使用缓存
+func requestEmbedding(data string, ollamaURL string, model string) ([]float32, error) {
 	reqBody := OllamaEmbedRequest{
 		Model:  model,
 		Prompt: data,
@@ -1156,74 +1578,5 @@ func getEmbedding(data string, ollamaURL string, model string) ([]float32, error
 		return nil, fmt.Errorf("error decoding response: %v", err)
 	}
 
-	// 将结果缓存
-	embeddingCache.Set(cacheKey, embedResp.Embedding)
-	fmt.Printf("   💾 已缓存结果 (缓存大小: %d)\n", embeddingCache.Size())
-
 	return embedResp.Embedding, nil
 }
-
-// getChatCompletion invokes the Ollama chat API to generate a response
-func getChatCompletion(prompt string, ollamaURL string, model string) (string, error) {
-	// 生成缓存键
-	cacheKey := getCacheKey(prompt)
-
-	// 尝试从缓存获取
-	if cachedAnswer, found := qaCache.Get(cacheKey); found {
-		fmt.Printf("   💾 使用问答缓存 (问答缓存大小: %d)\n", qaCache.Size())
-		fmt.Printf("   📝 上下文长度: %d 字符\n", len(prompt))
-		fmt.Printf("   🤖 使用模型: %s\n", model)
-		fmt.Printf("   📊 响应长度: %d 字符\n", len(cachedAnswer))
-		return cachedAnswer, nil
-	}
-
-	// 记录请求的详细信息
-	promptLen := len(prompt)
-	fmt.Printf("   🔄 未找到问答缓存，调用LLM API (问答缓存大小: %d)\n", qaCache.Size())
-	fmt.Printf("   📝 上下文长度: %d 字符\n", promptLen)
-	fmt.Printf("   🤖 使用模型: %s\n", model)
-
-	reqBody := OllamaChatRequest{
-		Model:  model,
-		Prompt: prompt,
-		Stream: false,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
-	}
-
-	// 记录网络请求时间
-	networkStart := time.Now()
-	resp, err := http.Post(ollamaURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("error making request: %v", err)
-	}
-	defer resp.Body.Close()
-	networkTime := time.Since(networkStart)
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API error: %s", string(body))
-	}
-
-	// 记录响应解析时间
-	parseStart := time.Now()
-	var chatResp OllamaChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return "", fmt.Errorf("error decoding response: %v", err)
-	}
-	parseTime := time.Since(parseStart)
-
-	// 输出详细的性能信息
-	fmt.Printf("   📊 网络请求时间: %v\n", networkTime)
-	fmt.Printf("   📊 响应解析时间: %v\n", parseTime)
-	fmt.Printf("   📊 响应长度: %d 字符\n", len(chatResp.Response))
-
-	// 将结果缓存
-	qaCache.Set(cacheKey, chatResp.Response)
-	fmt.Printf("   💾 已缓存问答结果 (问答缓存大小: %d)\n", qaCache.Size())
-
-	return chatResp.Response, nil
-}
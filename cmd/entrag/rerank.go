@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Reranker 对检索候选重新打分，得分越高越相关
+type Reranker interface {
+	// Name 返回重排序器名称，用于输出
+	Name() string
+	// Score 返回每个候选与问题的相关性得分，顺序与hits一致
+	Score(ctx context.Context, question string, hits []*SearchHit) ([]float64, error)
+}
+
+// newReranker 根据配置创建重排序器，同时返回要重排序的候选数。
+// type为none时返回nil。llm重排序器通过g所在的后端调用rerank.llm.model。
+func newReranker(cfg *Config, g Generator) (Reranker, int, error) {
+	rc := cfg.Rerank
+	switch rc.Type {
+	case "none":
+		return nil, 0, nil
+	case "lexical":
+		return lexicalReranker{}, rc.Lexical.TopN, nil
+	case "llm":
+		generator, err := generatorFor(cfg, g, rc.LLM.Model)
+		if err != nil {
+			return nil, 0, err
+		}
+		return &llmReranker{generator: generator}, rc.LLM.TopN, nil
+	case "cross_encoder":
+		if rc.CrossEncoder.Model == "" {
+			return nil, 0, fmt.Errorf("rerank.cross_encoder.model is required")
+		}
+		return &crossEncoderReranker{url: rc.CrossEncoder.URL, model: rc.CrossEncoder.Model}, rc.CrossEncoder.TopN, nil
+	default:
+		return nil, 0, fmt.Errorf("unknown reranker %q", rc.Type)
+	}
+}
+
+// rerank 取融合排序前topN个候选交给重排序器打分，按新得分返回，其余候选被丢弃。
+// 重排序器的得分写入RerankScore，Score保留原有的融合得分。
+func rerank(ctx context.Context, r Reranker, question string, hits []*SearchHit, topN int) ([]*SearchHit, error) {
+	sorted := append([]*SearchHit(nil), hits...)
+	sortHits(sorted)
+	if topN > 0 && len(sorted) > topN {
+		sorted = sorted[:topN]
+	}
+	if len(sorted) == 0 {
+		return sorted, nil
+	}
+	scores, err := r.Score(ctx, question, sorted)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != len(sorted) {
+		return nil, fmt.Errorf("%s reranker returned %d scores for %d candidates", r.Name(), len(scores), len(sorted))
+	}
+	for i, h := range sorted {
+		score := scores[i]
+		h.RerankScore = &score
+	}
+	sortHits(sorted)
+	return sorted, nil
+}
+
+// lexicalReranker 以候选集合为语料计算BM25得分
+type lexicalReranker struct{}
+
+func (lexicalReranker) Name() string { return "lexical" }
+
+// BM25参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+func (lexicalReranker) Score(_ context.Context, question string, hits []*SearchHit) ([]float64, error) {
+	terms := uniqueTokens(tokenize(question))
+	docs := make([]map[string]int, len(hits))
+	lengths := make([]int, len(hits))
+	df := make(map[string]int)
+	total := 0
+	for i, h := range hits {
+		tokens := tokenize(h.Chunk.Data)
+		docs[i] = make(map[string]int)
+		for _, t := range tokens {
+			docs[i][t]++
+		}
+		for t := range docs[i] {
+			df[t]++
+		}
+		lengths[i] = len(tokens)
+		total += len(tokens)
+	}
+	avgLen := math.Max(float64(total)/float64(len(hits)), 1)
+
+	n := float64(len(hits))
+	scores := make([]float64, len(hits))
+	for i := range hits {
+		for _, t := range terms {
+			tf := float64(docs[i][t])
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(lengths[i])/avgLen)
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+	return scores, nil
+}
+
+// llmReranker 逐个询问聊天模型片段与问题的相关程度（pointwise）
+type llmReranker struct {
+	generator Generator
+}
+
+func (r *llmReranker) Name() string { return "llm" }
+
+// rerankPrompt 相关性打分的prompt
+const rerankPrompt = `请评估下面的文档片段与问题的相关程度，给出0到10之间的整数分数：10表示片段能直接回答问题，0表示完全无关。只输出分数，不要输出其他内容。
+
+问题: %s
+
+文档片段:
+%s
+
+分数:`
+
+var scorePattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+func (r *llmReranker) Score(ctx context.Context, question string, hits []*SearchHit) ([]float64, error) {
+	zero := 0.0
+	scores := make([]float64, len(hits))
+	for i, h := range hits {
+		reply, err := r.generator.Generate(ctx, fmt.Sprintf(rerankPrompt, question, h.Chunk.Data), GenerationOptions{Temperature: &zero}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("scoring chunk %d: %w", h.Chunk.ID, err)
+		}
+		scores[i] = parseRelevanceScore(reply)
+	}
+	return scores, nil
+}
+
+// parseRelevanceScore 解析模型回复中的第一个数字并限制在[0,10]，无法解析时为0
+func parseRelevanceScore(reply string) float64 {
+	m := scorePattern.FindString(reply)
+	if m == "" {
+		return 0
+	}
+	score, err := strconv.ParseFloat(m, 64)
+	if err != nil {
+		return 0
+	}
+	return math.Max(0, math.Min(10, score))
+}
+
+// crossEncoderReranker 调用OpenAI兼容的本地rerank接口（如llama.cpp server、
+// text-embeddings-inference、Infinity提供的 /v1/rerank）
+type crossEncoderReranker struct {
+	url   string
+	model string
+}
+
+func (r *crossEncoderReranker) Name() string { return "cross_encoder" }
+
+type rerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func (r *crossEncoderReranker) Score(ctx context.Context, question string, hits []*SearchHit) ([]float64, error) {
+	reqBody := rerankRequest{Model: r.model, Query: question, TopN: len(hits)}
+	for _, h := range hits {
+		reqBody.Documents = append(reqBody.Documents, h.Chunk.Data)
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %s", strings.TrimSpace(string(body)))
+	}
+	var rerankResp rerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rerankResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	// 接口未返回的候选取最低分
+	scores := make([]float64, len(hits))
+	scored := make([]bool, len(hits))
+	lowest := math.Inf(1)
+	for _, res := range rerankResp.Results {
+		if res.Index < 0 || res.Index >= len(hits) {
+			return nil, fmt.Errorf("rerank result index %d out of range", res.Index)
+		}
+		scores[res.Index], scored[res.Index] = res.RelevanceScore, true
+		lowest = math.Min(lowest, res.RelevanceScore)
+	}
+	if math.IsInf(lowest, 1) {
+		return nil, fmt.Errorf("rerank endpoint returned no results")
+	}
+	for i := range scores {
+		if !scored[i] {
+			scores[i] = lowest
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rotemtam/entrag/ent"
+)
+
+func rerankHits(texts ...string) []*SearchHit {
+	hits := make([]*SearchHit, len(texts))
+	for i, text := range texts {
+		hits[i] = &SearchHit{
+			Chunk: &ent.Chunk{ID: i + 1, Path: "doc.md", Nchunk: i, Data: text},
+			// 融合得分按输入顺序递减
+			Score: float64(len(texts) - i),
+		}
+	}
+	return hits
+}
+
+func TestLexicalRerank(t *testing.T) {
+	hits := rerankHits(
+		"Ent is an entity framework for Go.",
+		"Indexes can use entsql.IndexType to choose GIN.",
+		"Hooks run before and after mutations.",
+	)
+	got, err := rerank(context.Background(), lexicalReranker{}, "How to set entsql.IndexType on an index?", hits, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected top 2 candidates, got %d", len(got))
+	}
+	if got[0].Chunk.ID != 2 {
+		t.Errorf("expected the index chunk first, got chunk %d", got[0].Chunk.ID)
+	}
+}
+
+func TestParseRelevanceScore(t *testing.T) {
+	tests := map[string]float64{
+		"8":           8,
+		"分数: 7.5":     7.5,
+		"42":          10,
+		"not related": 0,
+	}
+	for reply, want := range tests {
+		if got := parseRelevanceScore(reply); got != want {
+			t.Errorf("parseRelevanceScore(%q) = %v, want %v", reply, got, want)
+		}
+	}
+}
+
+func TestCrossEncoderRerank(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rerankRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Model != "bge-reranker" || len(req.Documents) != 3 {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		// 只返回两个结果，缺失的候选取最低分
+		w.Write([]byte(`{"results":[{"index":2,"relevance_score":0.9},{"index":0,"relevance_score":0.1}]}`))
+	}))
+	defer srv.Close()
+
+	r := &crossEncoderReranker{url: srv.URL, model: "bge-reranker"}
+	got, err := rerank(context.Background(), r, "question", rerankHits("a", "b", "c"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, h := range got {
+		ids = append(ids, h.Chunk.ID)
+	}
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 1 || ids[2] != 2 {
+		t.Errorf("unexpected order %v", ids)
+	}
+}
+
+func TestRerankKeepsFusedScore(t *testing.T) {
+	hits := rerankHits("a", "b", "c")
+	r := &crossEncoderReranker{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{"index":0,"relevance_score":0.2},{"index":1,"relevance_score":0.7},{"index":2,"relevance_score":0.5}]}`))
+	}))
+	defer srv.Close()
+	r.url = srv.URL
+
+	got, err := rerank(context.Background(), r, "question", hits, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantScores := map[int]float64{1: 3, 2: 2, 3: 1}
+	wantRerank := map[int]float64{1: 0.2, 2: 0.7, 3: 0.5}
+	for _, h := range got {
+		if h.Score != wantScores[h.Chunk.ID] {
+			t.Errorf("chunk %d: fused score changed to %v", h.Chunk.ID, h.Score)
+		}
+		if h.RerankScore == nil || *h.RerankScore != wantRerank[h.Chunk.ID] {
+			t.Errorf("chunk %d: unexpected rerank score %v", h.Chunk.ID, h.RerankScore)
+		}
+	}
+	if got[0].Chunk.ID != 2 || got[1].Chunk.ID != 3 || got[2].Chunk.ID != 1 {
+		t.Errorf("expected hits ordered by rerank score, got %d, %d, %d", got[0].Chunk.ID, got[1].Chunk.ID, got[2].Chunk.ID)
+	}
+
+	// 经过重排序的命中排在未重排序的命中之前，即使融合得分更低
+	plain := &SearchHit{Chunk: &ent.Chunk{ID: 9, Path: "doc.md"}, Score: 100}
+	if !hitLess(got[2], plain) || hitLess(plain, got[2]) {
+		t.Error("expected reranked hits before hits without a rerank score")
+	}
+}
+
+func TestLLMRerankUsesGenerator(t *testing.T) {
+	cfg := &Config{Rerank: RerankConfig{Type: "llm"}}
+	cfg.Ollama.ChatModel = "scripted"
+	cfg.applyDefaults()
+	gen := &scriptedGenerator{answers: []string{"2", "分数: 9", "5"}}
+	r, topN, err := newReranker(cfg, gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := rerank(context.Background(), r, "question", rerankHits("a", "b", "c"), topN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gen.prompts) != 3 || got[0].Chunk.ID != 2 || got[1].Chunk.ID != 3 {
+		t.Errorf("unexpected order after %d generations: %d, %d", len(gen.prompts), got[0].Chunk.ID, got[1].Chunk.ID)
+	}
+
+	// rerank.llm.model与回答模型不同时在配置的后端上单独创建
+	cfg.Rerank.LLM.Model = "other"
+	r, _, err = newReranker(cfg, gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := r.(*llmReranker).generator; g == Generator(gen) || g.Model() != "other" || g.Backend() != "ollama "+cfg.Ollama.URL {
+		t.Errorf("unexpected reranker generator %T %s", g, g.Model())
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rotemtam/entrag/ent"
+	"github.com/rotemtam/entrag/ent/chunk"
+	"github.com/rotemtam/entrag/ent/predicate"
+)
+
+// Retriever 检索与问题相关的片段
+type Retriever interface {
+	// Search 为问题检索片段
+	Search(ctx context.Context, question string, filter SearchFilter, opts searchOptions) (*retrievalResult, error)
+	// Neighbors 返回与各命中chunk同一文件、nchunk相差不超过window的chunk，
+	// 按路径和nchunk排序
+	Neighbors(ctx context.Context, hits []*SearchHit, window int) ([]*ent.Chunk, error)
+	// Embed 返回文本的向量，用于抽取式回答中的句子排序
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// dbRetriever 在数据库中检索，问题向量由Ollama生成
+type dbRetriever struct {
+	client *ent.Client
+	cfg    *Config
+	// generator 用于生成问题变体、翻译和LLM重排序，为nil时按配置创建
+	generator Generator
+
+	// classifier 在第一次检索时创建，embedding模式下示例问题的向量在命令中只计算一次
+	classifier *QueryClassifier
+}
+
+// Search 生成问题向量后执行智能检索
+func (r *dbRetriever) Search(ctx context.Context, question string, filter SearchFilter, opts searchOptions) (*retrievalResult, error) {
+	embedStart := time.Now()
+	_, cached := embeddingCache.Get(getCacheKey(question))
+	emb, err := getEmbedding(question, r.cfg.Ollama.URL, r.cfg.Ollama.EmbedModel)
+	if err != nil {
+		return nil, fmt.Errorf("error getting embedding: %v", err)
+	}
+	embedTime := time.Since(embedStart)
+	if r.classifier == nil {
+		r.classifier = newQueryClassifier(r.cfg.Classifier, func(text string) ([]float32, error) {
+			return r.Embed(ctx, text)
+		})
+	}
+	if r.generator == nil {
+		if r.generator, err = newGenerator(r.cfg); err != nil {
+			return nil, err
+		}
+	}
+	result, err := performIntelligentSearch(r.client, r.classifier, r.generator, emb, question, filter, opts, r.cfg)
+	if err != nil {
+		return nil, err
+	}
+	result.EmbedTime = embedTime
+	result.EmbedCache = cached
+	return result, nil
+}
+
+// Embed 使用向量缓存生成文本向量，不输出缓存信息
+func (r *dbRetriever) Embed(ctx context.Context, text string) ([]float32, error) {
+	cacheKey := getCacheKey(text)
+	if emb, found := embeddingCache.Get(cacheKey); found {
+		return emb, nil
+	}
+	emb, err := requestEmbedding(text, r.cfg.Ollama.URL, r.cfg.Ollama.EmbedModel)
+	if err != nil {
+		return nil, err
+	}
+	embeddingCache.Set(cacheKey, emb)
+	return emb, nil
+}
+
+// Neighbors 一次查询取回所有候选邻居
+func (r *dbRetriever) Neighbors(ctx context.Context, hits []*SearchHit, window int) ([]*ent.Chunk, error) {
+	var ranges []predicate.Chunk
+	for _, h := range hits {
+		ranges = append(ranges, chunk.And(
+			chunk.PathEQ(h.Chunk.Path),
+			chunk.NchunkGTE(h.Chunk.Nchunk-window),
+			chunk.NchunkLTE(h.Chunk.Nchunk+window),
+		))
+	}
+	return r.client.Chunk.Query().
+		Where(chunk.Or(ranges...)).
+		Order(ent.Asc(chunk.FieldPath), ent.Asc(chunk.FieldNchunk)).
+		All(ctx)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/pgvector/pgvector-go"
+	"github.com/rotemtam/entrag/ent"
+	"github.com/rotemtam/entrag/ent/chunk"
+	"github.com/rotemtam/entrag/ent/embedding"
+)
+
+// SearchHit 检索命中的片段，记录各路召回的排名与融合得分
+type SearchHit struct {
+	Chunk      *ent.Chunk
+	Embedding  *ent.Embedding // 仅全文命中且尚未建索引时为nil
+	VectorRank int            // 向量检索中的名次（从1开始，0表示未命中）
+	TextRank   int            // 全文检索中的名次（从1开始，0表示未命中）
+	Distance   float64        // 与问题向量的L2距离，无向量时为+Inf
+	Score      float64        // RRF融合得分
+	// RerankScore 重排序器的得分，尺度由重排序器决定，未经重排序时为nil
+	RerankScore *float64
+}
+
+// sortHits 按得分降序排列，经过重排序的命中排在前面并按重排序得分比较，
+// 得分相同时依次按距离、路径、nchunk和ID排序，保证相同的输入总是得到相同的顺序。
+func sortHits(hits []*SearchHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hitLess(hits[i], hits[j])
+	})
+}
+
+// hitLess 判断a是否应排在b之前
+func hitLess(a, b *SearchHit) bool {
+	if (a.RerankScore != nil) != (b.RerankScore != nil) {
+		return a.RerankScore != nil
+	}
+	if a.RerankScore != nil && *a.RerankScore != *b.RerankScore {
+		return *a.RerankScore > *b.RerankScore
+	}
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Distance != b.Distance {
+		return a.Distance < b.Distance
+	}
+	if a.Chunk.Path != b.Chunk.Path {
+		return a.Chunk.Path < b.Chunk.Path
+	}
+	if a.Chunk.Nchunk != b.Chunk.Nchunk {
+		return a.Chunk.Nchunk < b.Chunk.Nchunk
+	}
+	return a.Chunk.ID < b.Chunk.ID
+}
+
+// hybridSearch 并行执行向量检索和全文检索，并使用RRF融合两路结果
+func hybridSearch(ctx context.Context, client *ent.Client, emb []float32, question string, limit int, filter SearchFilter, cfg *Config) ([]*SearchHit, error) {
+	var (
+		wg                sync.WaitGroup
+		vecHits, textHits []*SearchHit
+		vecErr, textErr   error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		vecHits, vecErr = vectorSearch(ctx, client, emb, limit, filter)
+	}()
+	if cfg.Retrieval.Hybrid {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			textHits, textErr = textSearch(ctx, client, question, limit, filter)
+		}()
+	}
+	wg.Wait()
+
+	if vecErr != nil {
+		return nil, fmt.Errorf("vector search: %w", vecErr)
+	}
+	if textErr != nil {
+		return nil, fmt.Errorf("full-text search: %w", textErr)
+	}
+	// 仅全文命中的片段在Go侧计算距离
+	for _, h := range textHits {
+		if h.Embedding != nil {
+			h.Distance = l2Distance(emb, h.Embedding.Embedding.Slice())
+		}
+	}
+	return fuseRRF(vecHits, textHits, cfg.Retrieval), nil
+}
+
+// vectorSearch 按L2距离检索最相似的片段，过滤条件在同一条SQL中下推执行
+func vectorSearch(ctx context.Context, client *ent.Client, emb []float32, limit int, filter SearchFilter) ([]*SearchHit, error) {
+	embVec := pgvector.NewVector(emb)
+	query := client.Embedding.Query()
+	if preds := filter.predicates(); len(preds) > 0 {
+		query = query.Where(embedding.HasChunkWith(preds...))
+	}
+	embs, err := query.
+		Order(func(s *sql.Selector) {
+			s.OrderExpr(sql.Expr("distance"))
+			s.OrderBy(s.C(embedding.FieldID))
+		}).
+		WithChunk().
+		Limit(limit).
+		Modify(func(s *sql.Selector) {
+			s.AppendSelectExprAs(sql.ExprFunc(func(b *sql.Builder) {
+				b.Ident(s.C(embedding.FieldEmbedding)).WriteString(" <-> ").Arg(embVec)
+			}), "distance")
+		}).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]*SearchHit, 0, len(embs))
+	for i, e := range embs {
+		distance, err := selectedDistance(e)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, &SearchHit{
+			Chunk:      e.Edges.Chunk,
+			Embedding:  e,
+			VectorRank: i + 1,
+			Distance:   distance,
+		})
+	}
+	return hits, nil
+}
+
+// selectedDistance 读取查询中附加选择的distance列
+func selectedDistance(e *ent.Embedding) (float64, error) {
+	v, err := e.Value("distance")
+	if err != nil {
+		return 0, err
+	}
+	distance, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected distance type %T", v)
+	}
+	return distance, nil
+}
+
+// l2Distance 计算两个向量的L2距离，维度不一致时返回+Inf
+func l2Distance(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return math.Inf(1)
+	}
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// textSearch 使用Postgres全文检索召回片段。问题经tokenize切分后以OR连接，
+// 按ts_rank_cd排序（按文档长度归一化，近似BM25的效果），
+// 以便召回向量检索容易漏掉的精确标识符，如 entsql.OpClass。
+func textSearch(ctx context.Context, client *ent.Client, question string, limit int, filter SearchFilter) ([]*SearchHit, error) {
+	query := searchQuery(question)
+	if query == "" {
+		return nil, nil
+	}
+	tsQuery := func(b *sql.Builder) {
+		b.Arg(query).WriteString("::tsquery")
+	}
+	chunks, err := client.Chunk.
+		Query().
+		Where(filter.predicates()...).
+		Where(func(s *sql.Selector) {
+			s.Where(sql.P(func(b *sql.Builder) {
+				b.Ident(s.C(chunk.FieldTsv)).WriteString(" @@ ")
+				tsQuery(b)
+			}))
+		}).
+		Order(func(s *sql.Selector) {
+			s.OrderExpr(sql.ExprFunc(func(b *sql.Builder) {
+				b.WriteString("ts_rank_cd(").Ident(s.C(chunk.FieldTsv)).WriteString(", ")
+				tsQuery(b)
+				b.WriteString(", 1) DESC")
+			}))
+			s.OrderBy(s.C(chunk.FieldID))
+		}).
+		WithEmbedding().
+		Limit(limit).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]*SearchHit, 0, len(chunks))
+	for i, c := range chunks {
+		e := c.Edges.Embedding
+		if e != nil {
+			e.Edges.Chunk = c
+		}
+		hits = append(hits, &SearchHit{
+			Chunk:     c,
+			Embedding: e,
+			TextRank:  i + 1,
+			Distance:  math.Inf(1),
+		})
+	}
+	return hits, nil
+}
+
+// fuseRRF 使用倒数排名融合（Reciprocal Rank Fusion）合并两路检索结果：
+// score = Σ weight / (k + rank)
+func fuseRRF(vecHits, textHits []*SearchHit, cfg RetrievalConfig) []*SearchHit {
+	byChunk := make(map[int]*SearchHit)
+	var fused []*SearchHit
+	merge := func(h *SearchHit) *SearchHit {
+		if existing, ok := byChunk[h.Chunk.ID]; ok {
+			if existing.Embedding == nil {
+				existing.Embedding = h.Embedding
+			}
+			return existing
+		}
+		byChunk[h.Chunk.ID] = h
+		fused = append(fused, h)
+		return h
+	}
+	for _, h := range vecHits {
+		m := merge(h)
+		m.VectorRank = h.VectorRank
+		m.Score += *cfg.VectorWeight / float64(cfg.RRFK+h.VectorRank)
+	}
+	for _, h := range textHits {
+		m := merge(h)
+		m.TextRank = h.TextRank
+		m.Score += *cfg.TextWeight / float64(cfg.RRFK+h.TextRank)
+	}
+	sortHits(fused)
+	return fused
+}
+
+// backfillSearchVectors 为chunk计算全文检索向量。rebuild为false时只处理
+// 尚未计算过的chunk，为true时全部重新计算（分词规则变化后使用）。
+func backfillSearchVectors(ctx context.Context, client *ent.Client, rebuild bool) (int, error) {
+	query := client.Chunk.Query()
+	if !rebuild {
+		query = query.Where(chunk.TsvIsNil())
+	}
+	chunks, err := query.All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range chunks {
+		if err := client.Chunk.UpdateOne(c).SetTsv(searchVector(c.Data)).Exec(ctx); err != nil {
+			return 0, fmt.Errorf("updating chunk %d: %w", c.ID, err)
+		}
+	}
+	return len(chunks), nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/rotemtam/entrag/ent"
+)
+
+// testCandidates 构造一组包含同分、同距离片段的候选
+func testCandidates() []*SearchHit {
+	var hits []*SearchHit
+	paths := []string{"data/schema-edges.mdx", "data/hooks.md", "data/crud.mdx", "data/schema-fields.mdx"}
+	id := 1
+	for _, path := range paths {
+		for n := 0; n < 4; n++ {
+			hits = append(hits, &SearchHit{
+				Chunk: &ent.Chunk{
+					ID:     id,
+					Path:   path,
+					Nchunk: n,
+					Data:   fmt.Sprintf("How to define schema edges in ent, part %d of %s. %s", n, path, strings.Repeat("edge ", 40)),
+				},
+				// 每个文件中的片段两两同分，且部分距离相同
+				Score:    1.0 / float64(60+n/2),
+				Distance: float64(n/2) * 0.1,
+			})
+			id++
+		}
+	}
+	return hits
+}
+
+func TestSelectHitsDeterministic(t *testing.T) {
+	cfg := &Config{App: AppConfig{MaxSimilarChunks: 4, MinChunkSize: 10}}
+	cfg.applyDefaults()
+	question := "How to define schema edges?"
+	queryType := newQueryClassifier(cfg.Classifier, nil).Classify(question, nil)
+
+	render := func(hits []*SearchHit) string {
+		selected := selectHits(hits, question, queryType, cfg)
+		data := newPromptData(question, queryType, promptOptions{}, buildPassages(selected, nil))
+		prompt, _, err := renderPrompt(cfg.Prompts, queryType, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prompt
+	}
+
+	want := render(testCandidates())
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		hits := testCandidates()
+		rnd.Shuffle(len(hits), func(i, j int) { hits[i], hits[j] = hits[j], hits[i] })
+		if got := render(hits); got != want {
+			t.Fatalf("run %d: prompt differs for identical candidates:\n%s\n---\n%s", i, got, want)
+		}
+	}
+}
+
+func TestSortHitsTieBreaks(t *testing.T) {
+	hits := []*SearchHit{
+		{Chunk: &ent.Chunk{ID: 4, Path: "b.md", Nchunk: 0}, Score: 0.5, Distance: 0.2},
+		{Chunk: &ent.Chunk{ID: 3, Path: "a.md", Nchunk: 1}, Score: 0.5, Distance: 0.2},
+		{Chunk: &ent.Chunk{ID: 2, Path: "a.md", Nchunk: 0}, Score: 0.5, Distance: 0.2},
+		{Chunk: &ent.Chunk{ID: 1, Path: "c.md", Nchunk: 0}, Score: 0.5, Distance: 0.1},
+		{Chunk: &ent.Chunk{ID: 5, Path: "d.md", Nchunk: 0}, Score: 0.9, Distance: 0.9},
+	}
+	sortHits(hits)
+	var got []int
+	for _, h := range hits {
+		got = append(got, h.Chunk.ID)
+	}
+	if want := []int{5, 1, 2, 3, 4}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("sortHits() order = %v, want %v", got, want)
+	}
+}
+
+func TestWithinDistance(t *testing.T) {
+	hits := []*SearchHit{
+		{Chunk: &ent.Chunk{ID: 1}, Distance: 12},
+		{Chunk: &ent.Chunk{ID: 2}, Distance: 25},
+		{Chunk: &ent.Chunk{ID: 3}, Distance: math.Inf(1)}, // 仅全文命中，尚无向量
+	}
+	var ids []int
+	for _, h := range withinDistance(hits, 20) {
+		ids = append(ids, h.Chunk.ID)
+	}
+	if fmt.Sprint(ids) != "[1 3]" {
+		t.Errorf("withinDistance(20) kept %v, want [1 3]", ids)
+	}
+	if got := withinDistance(hits, 0); len(got) != 3 {
+		t.Errorf("withinDistance(0) kept %d hits, want all", len(got))
+	}
+}
+
+func TestDefaultMaxDistance(t *testing.T) {
+	var cfg Config
+	cfg.applyDefaults()
+	if *cfg.Retrieval.MaxDistance != defaultMaxDistance {
+		t.Errorf("default max_distance = %v", *cfg.Retrieval.MaxDistance)
+	}
+	// 显式设置为0时不过滤
+	zero := 0.0
+	cfg = Config{Retrieval: RetrievalConfig{MaxDistance: &zero}}
+	cfg.applyDefaults()
+	if *cfg.Retrieval.MaxDistance != 0 {
+		t.Errorf("max_distance: 0 was replaced with %v", *cfg.Retrieval.MaxDistance)
+	}
+}
+
+func TestFuseRRFZeroWeight(t *testing.T) {
+	vec := []*SearchHit{
+		{Chunk: &ent.Chunk{ID: 1, Path: "a.md"}, VectorRank: 1, Distance: 0.1},
+		{Chunk: &ent.Chunk{ID: 2, Path: "b.md"}, VectorRank: 2, Distance: 0.2},
+	}
+	text := []*SearchHit{
+		{Chunk: &ent.Chunk{ID: 2, Path: "b.md"}, TextRank: 1, Distance: math.Inf(1)},
+		{Chunk: &ent.Chunk{ID: 3, Path: "c.md"}, TextRank: 2, Distance: math.Inf(1)},
+	}
+	// text_weight: 0 时全文检索不影响排序，只按向量检索的名次排列
+	zero := 0.0
+	cfg := Config{Retrieval: RetrievalConfig{TextWeight: &zero}}
+	cfg.applyDefaults()
+	fused := fuseRRF(vec, text, cfg.Retrieval)
+	if fused[0].Chunk.ID != 1 || fused[1].Chunk.ID != 2 || fused[2].Score != 0 {
+		t.Errorf("unexpected fusion %v, %v, %v", fused[0].Chunk.ID, fused[1].Chunk.ID, fused[2].Score)
+	}
+}
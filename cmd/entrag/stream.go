@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// answerPrinter 在回答生成过程中逐段输出token。输出到终端时，生成完成后
+// 将流式输出的原始文本替换为glamour渲染的结果（回答比屏幕高时在其后输出渲染结果）；
+// 输出到管道或文件时保留原始Markdown。
+type answerPrinter struct {
+	w       io.Writer
+	header  string // 第一个token之前输出的标题
+	tty     bool
+	width   int // 终端列数
+	height  int // 终端行数
+	started bool
+	text    strings.Builder
+}
+
+// newAnswerPrinter 创建输出到w的answerPrinter，w为终端时启用渲染
+func newAnswerPrinter(w io.Writer) *answerPrinter {
+	p := &answerPrinter{w: w, header: "💬 回答:"}
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		p.tty = true
+		p.width, p.height, _ = term.GetSize(int(f.Fd()))
+	}
+	return p
+}
+
+// Write 输出新到达的token
+func (p *answerPrinter) Write(token string) {
+	if !p.started {
+		p.started = true
+		fmt.Fprintf(p.w, "\n%s\n", p.header)
+	}
+	p.text.WriteString(token)
+	fmt.Fprint(p.w, token)
+}
+
+// Finish 结束流式输出。终端中若原始文本仍完整显示在屏幕内，则擦除后输出
+// glamour渲染结果；文本已滚出屏幕时无法擦除，在原始文本之后输出渲染结果。
+func (p *answerPrinter) Finish() error {
+	return p.FinishWith(p.text.String(), "")
+}
+
+// FinishWith 与Finish相同，但终端中渲染的是final（如标注了代码错误的回答）；
+// 输出到管道或文件时不渲染，改为在原始文本后输出note。
+func (p *answerPrinter) FinishWith(final, note string) error {
+	if !p.started {
+		return nil
+	}
+	raw := p.text.String()
+	if !strings.HasSuffix(raw, "\n") {
+		fmt.Fprintln(p.w)
+	}
+	if !p.tty {
+		fmt.Fprint(p.w, note)
+		return nil
+	}
+	out, err := glamour.Render(final, "dark")
+	if err != nil {
+		return fmt.Errorf("error rendering markdown: %v", err)
+	}
+	if rows := terminalRows(raw, p.width); p.height > 0 && rows < p.height {
+		// 光标上移到原始文本开头并清除到屏幕末尾
+		fmt.Fprintf(p.w, "\x1b[%dA\x1b[J%s", rows, out)
+		return nil
+	}
+	// 回答比屏幕高，开头已滚出屏幕无法擦除，在原始文本后输出渲染结果
+	fmt.Fprintf(p.w, "\n🎨 渲染后的回答:\n%s", out)
+	return nil
+}
+
+// terminalRows 计算文本在给定列数的终端中占用的行数
+func terminalRows(text string, width int) int {
+	text = strings.TrimSuffix(text, "\n")
+	rows := 0
+	for _, line := range strings.Split(text, "\n") {
+		w := runewidth.StringWidth(line)
+		if width <= 0 || w <= width {
+			rows++
+			continue
+		}
+		rows += (w + width - 1) / width
+	}
+	return rows
+}
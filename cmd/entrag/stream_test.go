@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// useTempQACache 在测试期间使用空的问答缓存
+func useTempQACache(t *testing.T) {
+	dir, err := os.MkdirTemp("", "entrag-qa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := qaCache
+	qaCache = &QACache{cache: make(map[string]string), cacheDir: dir}
+	t.Cleanup(func() {
+		qaCache = old
+		// 缓存异步写盘，忽略删除时的竞争
+		os.RemoveAll(dir)
+	})
+}
+
+// ollamaStream 返回按行输出流式响应的Ollama模拟服务
+func ollamaStream(lines ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+			w.(http.Flusher).Flush()
+		}
+	}))
+}
+
+func TestGenerateAnswerStreams(t *testing.T) {
+	useTempQACache(t)
+	srv := ollamaStream(
+		`{"response":"Ent is ","done":false}`,
+		`{"response":"an ORM.","done":false}`,
+		`{"response":"","done":true}`,
+	)
+	defer srv.Close()
+
+	var tokens []string
+	answer, hit, err := generateAnswer(context.Background(), io.Discard, &ollamaGenerator{url: srv.URL, model: "m"}, "prompt", GenerationOptions{}, func(s string) {
+		tokens = append(tokens, s)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "Ent is an ORM." || hit || len(tokens) != 2 {
+		t.Fatalf("unexpected answer %q (cache hit %v, tokens %q)", answer, hit, tokens)
+	}
+
+	// 第二次从缓存返回，整个回答作为一段输出
+	tokens = nil
+	answer, hit, err = generateAnswer(context.Background(), io.Discard, &ollamaGenerator{url: srv.URL, model: "m"}, "prompt", GenerationOptions{}, func(s string) {
+		tokens = append(tokens, s)
+	})
+	if err != nil || !hit || answer != "Ent is an ORM." || len(tokens) != 1 {
+		t.Fatalf("expected cached answer, got %q (cache hit %v, tokens %q, err %v)", answer, hit, tokens, err)
+	}
+}
+
+func TestGenerateAnswerIncompleteNotCached(t *testing.T) {
+	useTempQACache(t)
+	srv := ollamaStream(`{"response":"Ent is ","done":false}`)
+	defer srv.Close()
+
+	if _, _, err := generateAnswer(context.Background(), io.Discard, &ollamaGenerator{url: srv.URL, model: "m"}, "prompt", GenerationOptions{}, nil); err == nil {
+		t.Fatal("expected an error for a truncated stream")
+	}
+	if qaCache.Size() != 0 {
+		t.Errorf("incomplete answer must not be cached")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := generateAnswer(ctx, io.Discard, &ollamaGenerator{url: srv.URL, model: "m"}, "prompt", GenerationOptions{}, nil); err == nil {
+		t.Fatal("expected an error for a cancelled generation")
+	}
+	if qaCache.Size() != 0 {
+		t.Errorf("cancelled answer must not be cached")
+	}
+}
+
+func TestTerminalRows(t *testing.T) {
+	text := "short\n" + strings.Repeat("x", 25) + "\n中文中文中文\n"
+	// 5列：short占1行，25个x占5行，12列宽的中文占3行
+	if got := terminalRows(text, 5); got != 9 {
+		t.Errorf("terminalRows = %d, want 9", got)
+	}
+	if got := terminalRows(text, 0); got != 3 {
+		t.Errorf("terminalRows without width = %d, want 3", got)
+	}
+}
+
+func TestAnswerPrinterFinish(t *testing.T) {
+	answer := "# Edges\n\nUse `edge.To` to define **edges**.\n"
+	for _, tc := range []struct {
+		name   string
+		height int
+		erase  bool
+	}{
+		{"fits", 40, true},
+		{"taller than the terminal", 3, false},
+		{"unknown size", 0, false},
+	} {
+		var out strings.Builder
+		p := &answerPrinter{w: &out, header: "💬 回答:", tty: true, width: 80, height: tc.height}
+		for _, token := range strings.SplitAfter(answer, " ") {
+			p.Write(token)
+		}
+		if err := p.Finish(); err != nil {
+			t.Fatal(err)
+		}
+		got := out.String()
+		if erased := strings.Contains(got, "\x1b[J"); erased != tc.erase {
+			t.Errorf("%s: erased the raw answer = %v, want %v", tc.name, erased, tc.erase)
+		}
+		// 无论能否擦除，终端中都要输出渲染结果
+		if !strings.Contains(got, "\x1b[") || strings.Count(got, "Edges") < 2 {
+			t.Errorf("%s: expected a rendered copy after the raw answer:\n%q", tc.name, got)
+		}
+		if !tc.erase && !strings.Contains(got, "🎨 渲染后的回答") {
+			t.Errorf("%s: expected the rendered answer header:\n%q", tc.name, got)
+		}
+	}
+
+	// 非终端只输出原始文本和note
+	var out strings.Builder
+	p := &answerPrinter{w: &out, header: "💬 回答:"}
+	p.Write(answer)
+	if err := p.FinishWith(answer, "note\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "\n💬 回答:\n"+answer+"note\n" {
+		t.Errorf("unexpected non-tty output %q", got)
+	}
+}
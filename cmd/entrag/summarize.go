@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rotemtam/entrag/ent"
+	"github.com/rotemtam/entrag/ent/chunk"
+)
+
+// summarizeMapPrompt 概括文档中一组连续chunk的prompt
+const summarizeMapPrompt = `下面是文档 %s 的第 %d/%d 部分。请用%s概括这部分的要点，
+保留关键的概念、API名称、配置项和操作步骤，不要添加文档中没有的内容，只输出摘要。
+
+%s`
+
+// summarizeReducePrompt 将多段摘要合并为一份摘要的prompt
+const summarizeReducePrompt = `下面是%s的 %d 段摘要，按原文顺序排列。请用%s将它们合并为一份连贯的摘要，
+去除重复的内容，保留关键的概念、API名称、配置项和操作步骤，只输出摘要。
+
+%s`
+
+// SummarizeCmd summarizes a document, or all documents under a path prefix,
+// by mapping over the stored chunks and reducing the partial summaries.
+type SummarizeCmd struct {
+	Path    string `required:"" help:"Path of a loaded document or directory, in full or as trailing path segments such as versioned/."`
+	Lang    string `help:"Summary language (e.g. zh, en); defaults to the language of the documents."`
+	Verbose bool   `short:"v" help:"Show every map and reduce call."`
+}
+
+// summarizer 在模型的token预算内对文本做map-reduce摘要
+type summarizer struct {
+	generator   Generator
+	opts        GenerationOptions
+	countTokens func(string) int
+	budget      int    // 一次调用中可用于文本的token数
+	lang        string // 摘要语言
+	log         io.Writer
+
+	calls  int // 模型调用次数
+	cached int // 来自缓存的文档摘要数
+}
+
+// document 一个文档按nchunk排序的chunk
+type document struct {
+	Path   string
+	Chunks []*ent.Chunk
+}
+
+// groupDocuments 将按路径和nchunk排序的chunk按文档分组
+func groupDocuments(chunks []*ent.Chunk) []document {
+	var docs []document
+	for _, c := range chunks {
+		if n := len(docs); n == 0 || docs[n-1].Path != c.Path {
+			docs = append(docs, document{Path: c.Path})
+		}
+		docs[len(docs)-1].Chunks = append(docs[len(docs)-1].Chunks, c)
+	}
+	return docs
+}
+
+// contentHash 计算文档内容的哈希，内容不变时摘要可以复用
+func (d document) contentHash() string {
+	h := sha256.New()
+	for _, c := range d.Chunks {
+		fmt.Fprintf(h, "%d\n%s\n", c.Nchunk, c.Data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// texts 返回各chunk的文本，相邻chunk中chunk_overlap产生的重复部分只保留一次
+func (d document) texts() []string {
+	texts := make([]string, len(d.Chunks))
+	for i, c := range d.Chunks {
+		texts[i] = c.Data
+		if i > 0 && d.Chunks[i-1].Nchunk+1 == c.Nchunk {
+			prev := d.Chunks[i-1].Data
+			texts[i] = strings.TrimPrefix(mergeOverlap(prev, c.Data)[len(prev):], "\n")
+		}
+	}
+	return texts
+}
+
+// generate 调用模型并计数
+func (s *summarizer) generate(ctx context.Context, step, prompt string) (string, error) {
+	s.calls++
+	start := time.Now()
+	reply, err := s.generator.Generate(ctx, prompt, s.opts, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", step, err)
+	}
+	fmt.Fprintf(s.log, "   🤖 %s (⏱️ %v, %d → %d tokens)\n", step, time.Since(start), s.countTokens(prompt), s.countTokens(reply))
+	return strings.TrimSpace(reply), nil
+}
+
+// cacheKey 返回摘要的缓存键，包含模型、生成参数和摘要语言
+func (s *summarizer) cacheKey(kind, hash string) string {
+	return generationCacheKey(s.generator, fmt.Sprintf("summary\n%s\n%s\n%s", kind, s.lang, hash), s.opts)
+}
+
+// summarizeDocument 按nchunk顺序将chunk分批概括，再逐层合并为文档摘要。
+// 摘要按文档内容的哈希缓存，第二个返回值表示摘要来自缓存。
+func (s *summarizer) summarizeDocument(ctx context.Context, doc document) (string, bool, error) {
+	key := s.cacheKey("document", doc.contentHash())
+	if cached, found := qaCache.Get(key); found {
+		s.cached++
+		return cached, true, nil
+	}
+
+	texts := doc.texts()
+	overhead := s.countTokens(fmt.Sprintf(summarizeMapPrompt, doc.Path, 1, 1, languageName(s.lang), ""))
+	batches := batchTexts(texts, s.budget-overhead, s.countTokens, 1)
+	partials := make([]string, len(batches))
+	for i, b := range batches {
+		prompt := fmt.Sprintf(summarizeMapPrompt, doc.Path, i+1, len(batches), languageName(s.lang), strings.Join(b, "\n\n"))
+		partial, err := s.generate(ctx, fmt.Sprintf("map %s [%d/%d]", doc.Path, i+1, len(batches)), prompt)
+		if err != nil {
+			return "", false, err
+		}
+		partials[i] = partial
+	}
+	summary, err := s.reduce(ctx, "文档 "+doc.Path, partials)
+	if err != nil {
+		return "", false, err
+	}
+	qaCache.Set(key, summary)
+	return summary, false, nil
+}
+
+// summarizeDocuments 概括每个文档后再合并为整体摘要，整体摘要按各文档内容的哈希缓存
+func (s *summarizer) summarizeDocuments(ctx context.Context, prefix string, docs []document) (string, error) {
+	if len(docs) == 1 {
+		summary, _, err := s.summarizeDocument(ctx, docs[0])
+		return summary, err
+	}
+	h := sha256.New()
+	for _, d := range docs {
+		fmt.Fprintf(h, "%s\n%s\n", d.Path, d.contentHash())
+	}
+	key := s.cacheKey("collection", hex.EncodeToString(h.Sum(nil)))
+	if cached, found := qaCache.Get(key); found {
+		s.cached += len(docs)
+		return cached, nil
+	}
+
+	summaries := make([]string, len(docs))
+	for i, d := range docs {
+		summary, cached, err := s.summarizeDocument(ctx, d)
+		if err != nil {
+			return "", err
+		}
+		if cached {
+			fmt.Fprintf(s.log, "   💾 %s 的摘要来自缓存\n", d.Path)
+		}
+		summaries[i] = fmt.Sprintf("### %s\n%s", d.Path, summary)
+	}
+	summary, err := s.reduce(ctx, fmt.Sprintf("%s 下 %d 个文档", prefix, len(docs)), summaries)
+	if err != nil {
+		return "", err
+	}
+	qaCache.Set(key, summary)
+	return summary, nil
+}
+
+// reduce 逐层合并摘要：每层将相邻的摘要按token预算分批合并，直到只剩一份
+func (s *summarizer) reduce(ctx context.Context, label string, summaries []string) (string, error) {
+	overhead := s.countTokens(fmt.Sprintf(summarizeReducePrompt, label, len(summaries), languageName(s.lang), ""))
+	for level := 1; len(summaries) > 1; level++ {
+		batches := batchTexts(summaries, s.budget-overhead, s.countTokens, 2)
+		var next []string
+		for i, b := range batches {
+			// 最后一批可能只剩一份摘要，直接进入下一层
+			if len(b) == 1 {
+				next = append(next, b[0])
+				continue
+			}
+			prompt := fmt.Sprintf(summarizeReducePrompt, label, len(b), languageName(s.lang), strings.Join(b, "\n\n"))
+			merged, err := s.generate(ctx, fmt.Sprintf("reduce %s L%d [%d/%d]", label, level, i+1, len(batches)), prompt)
+			if err != nil {
+				return "", err
+			}
+			next = append(next, merged)
+		}
+		summaries = next
+	}
+	return summaries[0], nil
+}
+
+// batchTexts 将相邻的文本按token预算分批，每批至少minItems个（文本足够时）。
+// 超出预算的批次中每段文本截断为预算的平均份额。
+func batchTexts(texts []string, budget int, countTokens func(string) int, minItems int) [][]string {
+	var batches [][]string
+	var current []string
+	used := 0
+	for _, t := range texts {
+		tokens := countTokens(t)
+		if len(current) >= minItems && used+tokens > budget {
+			batches = append(batches, current)
+			current, used = nil, 0
+		}
+		current = append(current, t)
+		used += tokens
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	for _, b := range batches {
+		total := 0
+		for _, t := range b {
+			total += countTokens(t)
+		}
+		if total <= budget {
+			continue
+		}
+		for i, t := range b {
+			b[i] = truncateToTokens(t, budget/len(b), countTokens)
+		}
+	}
+	return batches
+}
+
+// matchDocumentPath 判断chunk路径是否属于path指定的文档或目录。path按完整的路径段匹配，
+// 可以是路径的开头或中间部分：versioned/ 匹配 data/ent/versioned/intro.md，
+// intro.md 匹配 data/ent/intro.md，但 data/ent/intro 不匹配 data/ent/introduction.md。
+func matchDocumentPath(path, chunkPath string) bool {
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return false
+	}
+	return chunkPath == path ||
+		strings.HasPrefix(chunkPath, path+"/") ||
+		strings.HasSuffix(chunkPath, "/"+path) ||
+		strings.Contains(chunkPath, "/"+path+"/")
+}
+
+// findDocumentChunks 返回属于path指定的文档或目录的chunk，按路径和nchunk排序
+func findDocumentChunks(ctx context.Context, client *ent.Client, path string) ([]*ent.Chunk, error) {
+	chunks, err := client.Chunk.Query().
+		Where(chunk.PathContains(strings.TrimSuffix(path, "/"))).
+		Order(ent.Asc(chunk.FieldPath), ent.Asc(chunk.FieldNchunk)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*ent.Chunk
+	for _, c := range chunks {
+		if matchDocumentPath(path, c.Path) {
+			matched = append(matched, c)
+		}
+	}
+	return matched, nil
+}
+
+// Run is the method called when the "summarize" command is executed.
+func (cmd *SummarizeCmd) Run(cli *CLI) error {
+	cfg := cli.LoadedConfig()
+	client, err := cli.entClient()
+	if err != nil {
+		return fmt.Errorf("failed opening connection to postgres: %w", err)
+	}
+	generator, err := newGenerator(cfg)
+	if err != nil {
+		return err
+	}
+	countTokens, err := tokenCounter(cfg.App.TokenEncoding)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	chunks, err := findDocumentChunks(ctx, client, cmd.Path)
+	if err != nil {
+		return fmt.Errorf("error querying chunks: %v", err)
+	}
+	if len(chunks) == 0 {
+		return fmt.Errorf("no loaded documents match %q", cmd.Path)
+	}
+	docs := groupDocuments(chunks)
+
+	lang := cmd.Lang
+	if lang == "" {
+		lang = chunks[0].Lang
+	}
+	if lang == "" {
+		lang = "zh"
+	}
+	modelCfg := cfg.Ollama.ChatModelConfig(generator.Model())
+	s := &summarizer{
+		generator:   generator,
+		opts:        cfg.Ollama.Options,
+		countTokens: countTokens,
+		budget:      modelCfg.NumCtx - modelCfg.AnswerTokens,
+		lang:        lang,
+		log:         io.Discard,
+	}
+	if cmd.Verbose {
+		s.log = os.Stdout
+	}
+
+	fmt.Printf("📄 正在概括 %d 个文档 (%d 个chunk, 摘要语言: %s, 每次调用约 %d tokens)...\n", len(docs), len(chunks), languageName(lang), s.budget)
+	start := time.Now()
+	summary, err := s.summarizeDocuments(ctx, cmd.Path, docs)
+	if err != nil {
+		return fmt.Errorf("error summarizing: %v", err)
+	}
+
+	printer := newAnswerPrinter(os.Stdout)
+	printer.header = "📝 摘要:"
+	printer.Write(summary)
+	if err := printer.Finish(); err != nil {
+		return err
+	}
+	fmt.Printf("\n✅ 摘要完成 (⏱️ %v, 调用模型 %d 次, %d/%d 个文档的摘要来自缓存)\n", time.Since(start), s.calls, s.cached, len(docs))
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rotemtam/entrag/ent"
+)
+
+// countW 只统计字母w，使prompt模板本身不占token预算
+func countW(s string) int { return strings.Count(s, "w") }
+
+// newTestSummarizer 返回按预设回答摘要、每次调用可用10个token的summarizer
+func newTestSummarizer(answers ...string) (*summarizer, *scriptedGenerator) {
+	gen := &scriptedGenerator{answers: answers}
+	return &summarizer{generator: gen, countTokens: countW, budget: 10, lang: "zh", log: io.Discard}, gen
+}
+
+func TestBatchTexts(t *testing.T) {
+	texts := []string{"www", "www", "www", "wwwww"}
+	if got, want := batchTexts(texts, 6, countW, 1), [][]string{{"www", "www"}, {"www"}, {"wwwww"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("batchTexts(min 1) = %q, want %q", got, want)
+	}
+	// 每批至少两段时，超出预算的批次中每段截断为预算的一半
+	if got, want := batchTexts(texts, 6, countW, 2), [][]string{{"www", "www"}, {"www", "www"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("batchTexts(min 2) = %q, want %q", got, want)
+	}
+}
+
+func TestGroupDocuments(t *testing.T) {
+	chunks := []*ent.Chunk{{Path: "a.md", Nchunk: 0}, {Path: "a.md", Nchunk: 1}, {Path: "b.md", Nchunk: 0}}
+	docs := groupDocuments(chunks)
+	if len(docs) != 2 || docs[0].Path != "a.md" || len(docs[0].Chunks) != 2 || docs[1].Path != "b.md" {
+		t.Errorf("unexpected documents %+v", docs)
+	}
+}
+
+func TestMatchDocumentPath(t *testing.T) {
+	for _, tc := range []struct {
+		path, chunkPath string
+		want            bool
+	}{
+		{"versioned/", "data/ent/versioned/intro.md", true},
+		{"versioned", "data/ent/versioned/intro.md", true},
+		{"data/ent/versioned/", "data/ent/versioned/intro.md", true},
+		{"ent/versioned", "data/ent/versioned/intro.md", true},
+		{"intro.md", "data/ent/intro.md", true},
+		{"data/ent/intro.md", "data/ent/intro.md", true},
+		{"data/ent/intro", "data/ent/introduction.md", false},
+		{"versioned/", "data/ent/unversioned/intro.md", false},
+		{"intro.md", "data/ent/myintro.md", false},
+		{"/", "data/ent/intro.md", false},
+	} {
+		if got := matchDocumentPath(tc.path, tc.chunkPath); got != tc.want {
+			t.Errorf("matchDocumentPath(%q, %q) = %v, want %v", tc.path, tc.chunkPath, got, tc.want)
+		}
+	}
+}
+
+func TestDocumentTexts(t *testing.T) {
+	overlap := "shared overlap text between chunks"
+	doc := document{Path: "a.md", Chunks: []*ent.Chunk{
+		{Nchunk: 0, Data: "first part " + overlap},
+		{Nchunk: 1, Data: overlap + " second part"},
+		{Nchunk: 3, Data: overlap + " fourth part"},
+	}}
+	// 只有相邻的chunk去除重叠部分
+	want := []string{"first part " + overlap, " second part", overlap + " fourth part"}
+	if got := doc.texts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("texts() = %q, want %q", got, want)
+	}
+}
+
+func TestSummarizeDocument(t *testing.T) {
+	useTempQACache(t)
+	doc := document{Path: "data/ent/tutorial.md"}
+	for i := 0; i < 6; i++ {
+		doc.Chunks = append(doc.Chunks, &ent.Chunk{Path: doc.Path, Nchunk: i, Data: "w w w w w"})
+	}
+	partial := "w w w w w w"
+	s, gen := newTestSummarizer(partial, partial, partial, partial, "final summary")
+	summary, cached, err := s.summarizeDocument(context.Background(), doc)
+	if err != nil || cached || summary != "final summary" {
+		t.Fatalf("summarizeDocument() = %q, %v, %v", summary, cached, err)
+	}
+
+	// 6个chunk每两个一批得到3段摘要；第一层合并前两段，第三段直接进入第二层
+	if len(gen.prompts) != 5 {
+		t.Fatalf("expected 3 map and 2 reduce calls, got %d", len(gen.prompts))
+	}
+	for i, want := range []string{"第 1/3 部分", "第 2/3 部分", "第 3/3 部分", "2 段摘要", "2 段摘要"} {
+		if !strings.Contains(gen.prompts[i], want) {
+			t.Errorf("prompt %d does not contain %q:\n%s", i+1, want, gen.prompts[i])
+		}
+	}
+	if !strings.Contains(gen.prompts[0], "用中文概括") || strings.Count(gen.prompts[0], "w w w w w") != 2 {
+		t.Errorf("unexpected map prompt:\n%s", gen.prompts[0])
+	}
+
+	// 内容不变时摘要来自缓存，内容变化后重新生成
+	s, gen = newTestSummarizer("changed")
+	if summary, cached, _ := s.summarizeDocument(context.Background(), doc); !cached || summary != "final summary" || len(gen.prompts) != 0 {
+		t.Errorf("expected a cached summary, got %q (cached %v, %d calls)", summary, cached, len(gen.prompts))
+	}
+	doc.Chunks = doc.Chunks[:1]
+	if summary, cached, _ := s.summarizeDocument(context.Background(), doc); cached || summary != "changed" {
+		t.Errorf("expected a new summary for changed content, got %q (cached %v)", summary, cached)
+	}
+}
+
+func TestSummarizeDocuments(t *testing.T) {
+	useTempQACache(t)
+	docs := []document{
+		{Path: "versioned/a.md", Chunks: []*ent.Chunk{{Path: "versioned/a.md", Data: "w w"}}},
+		{Path: "versioned/b.md", Chunks: []*ent.Chunk{{Path: "versioned/b.md", Data: "w w w"}}},
+	}
+	s, gen := newTestSummarizer("summary a", "summary b", "overall")
+	summary, err := s.summarizeDocuments(context.Background(), "versioned/", docs)
+	if err != nil || summary != "overall" {
+		t.Fatalf("summarizeDocuments() = %q, %v", summary, err)
+	}
+	if reduce := gen.prompts[2]; !strings.Contains(reduce, "versioned/ 下 2 个文档") || !strings.Contains(reduce, "### versioned/a.md\nsummary a") {
+		t.Errorf("unexpected reduce prompt:\n%s", reduce)
+	}
+
+	// 只有b变化时，a的摘要来自缓存
+	docs[1].Chunks[0] = &ent.Chunk{Path: "versioned/b.md", Data: "w"}
+	s, gen = newTestSummarizer("summary b2", "overall 2")
+	if summary, err := s.summarizeDocuments(context.Background(), "versioned/", docs); err != nil || summary != "overall 2" {
+		t.Fatalf("summarizeDocuments() = %q, %v", summary, err)
+	}
+	if s.cached != 1 || s.calls != 2 {
+		t.Errorf("expected one cached document and 2 calls, got %d cached, %d calls", s.cached, s.calls)
+	}
+}
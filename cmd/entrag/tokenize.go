@@ -0,0 +1,437 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 英文停用词
+var englishStopWords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true, "as": true,
+	"at": true, "be": true, "but": true, "by": true, "can": true, "do": true,
+	"does": true, "for": true, "from": true, "how": true, "i": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "me": true,
+	"my": true, "not": true, "of": true, "on": true, "or": true, "should": true,
+	"so": true, "that": true, "the": true, "their": true, "them": true,
+	"then": true, "there": true, "these": true, "they": true, "this": true,
+	"to": true, "use": true, "using": true, "was": true, "we": true,
+	"what": true, "when": true, "where": true, "which": true, "who": true,
+	"why": true, "will": true, "with": true, "would": true, "you": true,
+	"your": true,
+}
+
+// 中文停用字：在这些字处切分中文片段，不参与二元组
+var cjkStopChars = map[rune]bool{
+	'的': true, '了': true, '和': true, '与': true, '及': true, '是': true,
+	'在': true, '吗': true, '呢': true, '吧': true, '啊': true, '把': true,
+	'被': true, '或': true, '也': true, '都': true, '就': true, '而': true,
+	'之': true, '请': true, '个': true,
+}
+
+// 中文停用词（二元组）
+var cjkStopWords = map[string]bool{
+	"什么": true, "怎么": true, "如何": true, "哪些": true, "为什": true,
+	"怎样": true, "可以": true, "一下": true, "这个": true, "那个": true,
+	"我们": true, "你们": true, "他们": true, "有哪": true, "问一": true,
+}
+
+// tokenize 将文本切分为用于词法匹配的词项：
+//   - 英文单词转小写、去除停用词并做Porter词干提取；
+//   - 带点号或下划线的标识符（如 entsql.OpClass）保留整体，并额外输出各段；
+//   - 中日韩文字在停用字处切分后输出二元组（单字片段输出单字）。
+func tokenize(text string) []string {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isCJK(r):
+			j := i
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, cjkTokens(runes[i:j])...)
+			i = j
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && (isWordRune(runes[j]) || isJoiner(runes, j)) {
+				j++
+			}
+			tokens = append(tokens, wordTokens(string(runes[i:j]))...)
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// uniqueTokens 返回去重后的词项，保持首次出现的顺序
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	var out []string
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// countKeywordMatches 统计问题词项在片段中出现的个数
+func countKeywordMatches(questionTokens []string, chunkText string) int {
+	chunkTokens := make(map[string]bool)
+	for _, t := range tokenize(chunkText) {
+		chunkTokens[t] = true
+	}
+	matches := 0
+	for _, t := range uniqueTokens(questionTokens) {
+		if chunkTokens[t] {
+			matches++
+		}
+	}
+	return matches
+}
+
+// searchVector 将文本编码为Postgres tsvector字面量（带位置信息），
+// 使全文检索与Go侧的分词保持一致。
+func searchVector(text string) string {
+	positions := make(map[string][]int)
+	var order []string
+	for i, t := range tokenize(text) {
+		if _, ok := positions[t]; !ok {
+			order = append(order, t)
+		}
+		// tsvector的位置上限为16383
+		if pos := i + 1; pos <= 16383 {
+			positions[t] = append(positions[t], pos)
+		}
+	}
+	sort.Strings(order)
+	var b strings.Builder
+	for i, t := range order {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(quoteLexeme(t))
+		for j, p := range positions[t] {
+			if j == 0 {
+				b.WriteByte(':')
+			} else {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%d", p)
+		}
+	}
+	return b.String()
+}
+
+// searchQuery 将问题编码为以OR连接的tsquery字面量，无词项时返回空串
+func searchQuery(question string) string {
+	tokens := uniqueTokens(tokenize(question))
+	quoted := make([]string, len(tokens))
+	for i, t := range tokens {
+		quoted[i] = quoteLexeme(t)
+	}
+	return strings.Join(quoted, " | ")
+}
+
+// quoteLexeme 按tsvector/tsquery字面量规则为词项加引号
+func quoteLexeme(t string) string {
+	t = strings.ReplaceAll(t, `\`, `\\`)
+	t = strings.ReplaceAll(t, `'`, `''`)
+	return "'" + t + "'"
+}
+
+// cjkTokens 将一段连续的中日韩文字切分为二元组
+func cjkTokens(run []rune) []string {
+	var tokens []string
+	emit := func(seg []rune) {
+		if len(seg) == 1 {
+			tokens = append(tokens, string(seg))
+			return
+		}
+		for k := 0; k+1 < len(seg); k++ {
+			if bigram := string(seg[k : k+2]); !cjkStopWords[bigram] {
+				tokens = append(tokens, bigram)
+			}
+		}
+	}
+	start := 0
+	for k, r := range run {
+		if cjkStopChars[r] {
+			if k > start {
+				emit(run[start:k])
+			}
+			start = k + 1
+		}
+	}
+	if start < len(run) {
+		emit(run[start:])
+	}
+	return tokens
+}
+
+// wordTokens 处理一个英文单词或标识符
+func wordTokens(word string) []string {
+	word = strings.Trim(word, "._")
+	if word == "" {
+		return nil
+	}
+	lower := strings.ToLower(word)
+	if !strings.ContainsAny(lower, "._") {
+		if englishStopWords[lower] {
+			return nil
+		}
+		if isPlainWord(word) {
+			return []string{porterStem(lower)}
+		}
+		return []string{lower}
+	}
+	// 标识符：保留整体，并输出各段以便部分匹配
+	tokens := []string{lower}
+	for _, part := range strings.FieldsFunc(lower, func(r rune) bool { return r == '.' || r == '_' }) {
+		if !englishStopWords[part] {
+			tokens = append(tokens, part)
+		}
+	}
+	return tokens
+}
+
+// isPlainWord 判断是否为普通英文单词（全小写或仅首字母大写），
+// 驼峰形式的标识符（如 WithChunk）不做词干提取。
+func isPlainWord(word string) bool {
+	for i, r := range word {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return false
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWordRune(r rune) bool {
+	return !isCJK(r) && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// isJoiner 判断位置i处的点号是否连接两个单词字符（如 entsql.OpClass）
+func isJoiner(runes []rune, i int) bool {
+	return runes[i] == '.' && i > 0 && i+1 < len(runes) &&
+		isWordRune(runes[i-1]) && isWordRune(runes[i+1])
+}
+
+// porterStem 实现Porter词干提取算法
+// (M.F. Porter, "An algorithm for suffix stripping", 1980)。
+func porterStem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	w := []byte(word)
+	w = porterStep1a(w)
+	w = porterStep1b(w)
+	w = porterStep1c(w)
+	w = porterStep2(w)
+	w = porterStep3(w)
+	w = porterStep4(w)
+	w = porterStep5(w)
+	return string(w)
+}
+
+// isConsonant 判断w[i]是否为辅音字母
+func isConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	}
+	return true
+}
+
+// measure 计算 [C](VC)^m[V] 中的m
+func measure(w []byte) int {
+	m, i, n := 0, 0, len(w)
+	for i < n && isConsonant(w, i) {
+		i++
+	}
+	for i < n {
+		for i < n && !isConsonant(w, i) {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		for i < n && isConsonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func containsVowel(w []byte) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC 判断是否以 辅音-元音-辅音 结尾，且最后的辅音不是w、x、y
+func endsCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-1) || isConsonant(w, n-2) || !isConsonant(w, n-3) {
+		return false
+	}
+	c := w[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+func hasSuffix(w []byte, s string) bool {
+	return len(w) >= len(s) && string(w[len(w)-len(s):]) == s
+}
+
+// replaceSuffix 在词干的m大于minM时将后缀s替换为r
+func replaceSuffix(w []byte, s, r string, minM int) ([]byte, bool) {
+	if !hasSuffix(w, s) {
+		return w, false
+	}
+	stem := w[:len(w)-len(s)]
+	if measure(stem) > minM {
+		return append(stem[:len(stem):len(stem)], r...), true
+	}
+	return w, true
+}
+
+func porterStep1a(w []byte) []byte {
+	switch {
+	case hasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func porterStep1b(w []byte) []byte {
+	if hasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+	var stem []byte
+	switch {
+	case hasSuffix(w, "ed") && containsVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case hasSuffix(w, "ing") && containsVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem[:len(stem):len(stem)], 'e')
+	case endsDoubleConsonant(stem):
+		if c := stem[len(stem)-1]; c != 'l' && c != 's' && c != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case measure(stem) == 1 && endsCVC(stem):
+		return append(stem[:len(stem):len(stem)], 'e')
+	}
+	return stem
+}
+
+func porterStep1c(w []byte) []byte {
+	if hasSuffix(w, "y") && containsVowel(w[:len(w)-1]) {
+		out := append([]byte{}, w...)
+		out[len(out)-1] = 'i'
+		return out
+	}
+	return w
+}
+
+var porterStep2Suffixes = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+func porterStep2(w []byte) []byte {
+	for _, s := range porterStep2Suffixes {
+		if out, matched := replaceSuffix(w, s[0], s[1], 0); matched {
+			return out
+		}
+	}
+	return w
+}
+
+var porterStep3Suffixes = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+func porterStep3(w []byte) []byte {
+	for _, s := range porterStep3Suffixes {
+		if out, matched := replaceSuffix(w, s[0], s[1], 0); matched {
+			return out
+		}
+	}
+	return w
+}
+
+var porterStep4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func porterStep4(w []byte) []byte {
+	for _, s := range porterStep4Suffixes {
+		if !hasSuffix(w, s) {
+			continue
+		}
+		stem := w[:len(w)-len(s)]
+		if s == "ion" && (len(stem) == 0 || (stem[len(stem)-1] != 's' && stem[len(stem)-1] != 't')) {
+			return w
+		}
+		if measure(stem) > 1 {
+			return stem
+		}
+		return w
+	}
+	return w
+}
+
+func porterStep5(w []byte) []byte {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := measure(stem); m > 1 || (m == 1 && !endsCVC(stem)) {
+			w = stem
+		}
+	}
+	if measure(w) > 1 && endsDoubleConsonant(w) && hasSuffix(w, "l") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeCJK(t *testing.T) {
+	tokens := tokenize("PDM和PLM系统的区别是什么")
+	for _, want := range []string{"pdm", "plm", "系统", "区别"} {
+		if !containsToken(tokens, want) {
+			t.Errorf("tokenize() = %q, missing %q", tokens, want)
+		}
+	}
+	for _, stop := range []string{"什么", "的区", "和"} {
+		if containsToken(tokens, stop) {
+			t.Errorf("tokenize() = %q, unexpected stop word %q", tokens, stop)
+		}
+	}
+}
+
+func TestTokenizeEnglish(t *testing.T) {
+	tokens := tokenize("How to define relationships using entsql.OpClass and WithChunk?")
+	for _, want := range []string{"defin", "relationship", "entsql.opclass", "entsql", "opclass", "withchunk"} {
+		if !containsToken(tokens, want) {
+			t.Errorf("tokenize() = %q, missing %q", tokens, want)
+		}
+	}
+	for _, stop := range []string{"how", "to", "and", "using"} {
+		if containsToken(tokens, stop) {
+			t.Errorf("tokenize() = %q, unexpected stop word %q", tokens, stop)
+		}
+	}
+}
+
+// TestPorterStem 使用Porter论文中各步骤的示例以及官方样例词表
+// (tartarus.org/martin/PorterStemmer/voc.txt) 中的片段校验词干提取。
+func TestPorterStem(t *testing.T) {
+	tests := []struct{ word, want string }{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"ties", "ti"},
+		{"caress", "caress"},
+		{"cats", "cat"},
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"bled", "bled"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"troubled", "troubl"},
+		{"sized", "size"},
+		{"hopping", "hop"},
+		{"tanned", "tan"},
+		{"falling", "fall"},
+		{"hissing", "hiss"},
+		{"fizzed", "fizz"},
+		{"failing", "fail"},
+		{"filing", "file"},
+		{"happy", "happi"},
+		{"sky", "sky"},
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"rational", "ration"},
+		{"valenci", "valenc"},
+		{"hesitanci", "hesit"},
+		{"digitizer", "digit"},
+		{"conformabli", "conform"},
+		{"radicalli", "radic"},
+		{"differentli", "differ"},
+		{"vileli", "vile"},
+		{"analogousli", "analog"},
+		{"vietnamization", "vietnam"},
+		{"predication", "predic"},
+		{"operator", "oper"},
+		{"feudalism", "feudal"},
+		{"decisiveness", "decis"},
+		{"hopefulness", "hope"},
+		{"callousness", "callous"},
+		{"formaliti", "formal"},
+		{"sensitiviti", "sensit"},
+		{"sensibiliti", "sensibl"},
+		{"triplicate", "triplic"},
+		{"formative", "form"},
+		{"formalize", "formal"},
+		{"electriciti", "electr"},
+		{"electrical", "electr"},
+		{"hopeful", "hope"},
+		{"goodness", "good"},
+		{"revival", "reviv"},
+		{"allowance", "allow"},
+		{"inference", "infer"},
+		{"airliner", "airlin"},
+		{"gyroscopic", "gyroscop"},
+		{"adjustable", "adjust"},
+		{"defensible", "defens"},
+		{"irritant", "irrit"},
+		{"replacement", "replac"},
+		{"adjustment", "adjust"},
+		{"dependent", "depend"},
+		{"adoption", "adopt"},
+		{"homologou", "homolog"},
+		{"communism", "commun"},
+		{"activate", "activ"},
+		{"angulariti", "angular"},
+		{"homologous", "homolog"},
+		{"effective", "effect"},
+		{"bowdlerize", "bowdler"},
+		{"probate", "probat"},
+		{"rate", "rate"},
+		{"cease", "ceas"},
+		{"controll", "control"},
+		{"roll", "roll"},
+		{"generalizations", "gener"},
+		{"oscillators", "oscil"},
+		// 官方样例词表
+		{"consign", "consign"},
+		{"consigned", "consign"},
+		{"consignment", "consign"},
+		{"consistency", "consist"},
+		{"consolation", "consol"},
+		{"consolidate", "consolid"},
+		{"conspiracy", "conspiraci"},
+		{"constable", "constabl"},
+		{"constance", "constanc"},
+		{"knack", "knack"},
+		{"knackeries", "knackeri"},
+		{"kneeling", "kneel"},
+		{"knightly", "knightli"},
+		{"knitting", "knit"},
+		{"knives", "knive"},
+		{"knocker", "knocker"},
+		// 文档中常见的词
+		{"generalizing", "gener"},
+		{"edges", "edg"},
+		{"migrations", "migrat"},
+	}
+	for _, tt := range tests {
+		if got := porterStem(tt.word); got != tt.want {
+			t.Errorf("porterStem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+// TestKeywordMatchesChineseCorpus 使用 data/cn 中的中文语料验证：
+// 按空白切分的旧方法无法匹配任何段落，而新的分词可以找到相关段落。
+func TestKeywordMatchesChineseCorpus(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "data", "cn", "pdm.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	question := "PDM和PLM系统的区别是什么"
+
+	legacyMatched, matched := 0, 0
+	best, bestScore := "", 0
+	for _, line := range strings.Split(string(data), "\n") {
+		lower := strings.ToLower(line)
+		for _, word := range strings.Fields(strings.ToLower(question)) {
+			if len(word) > 2 && strings.Contains(lower, word) {
+				legacyMatched++
+				break
+			}
+		}
+		if score := countKeywordMatches(tokenize(question), line); score > 0 {
+			matched++
+			if score > bestScore {
+				best, bestScore = line, score
+			}
+		}
+	}
+	if legacyMatched != 0 {
+		t.Fatalf("legacy matching unexpectedly matched %d lines", legacyMatched)
+	}
+	if matched == 0 {
+		t.Fatal("tokenized matching found no lines")
+	}
+	if !strings.Contains(best, "PDM") || !strings.Contains(best, "PLM") {
+		t.Errorf("best matching line should mention both PDM and PLM, got %q", best)
+	}
+}
+
+func TestSearchVector(t *testing.T) {
+	got := searchVector("schema edges, schema fields")
+	want := "'edg':2 'field':4 'schema':1,3"
+	if got != want {
+		t.Errorf("searchVector() = %q, want %q", got, want)
+	}
+	if got := searchQuery("what is it"); got != "" {
+		t.Errorf("searchQuery() = %q, want empty", got)
+	}
+}
+
+func containsToken(tokens []string, want string) bool {
+	for _, t := range tokens {
+		if t == want {
+			return true
+		}
+	}
+	return false
+}
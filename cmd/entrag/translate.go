@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 跨语言检索的翻译方式
+const (
+	translateLLM      = "llm"      // 由聊天模型翻译问题
+	translateGlossary = "glossary" // 按双语术语表替换问题中的术语
+)
+
+// translatePrompt 把问题翻译为另一种语言的prompt
+const translatePrompt = `请把下面的问题翻译成%s，用于检索%s写的技术文档。
+专有名词、代码和标识符保持原样，只输出译文，不要输出其他内容。
+
+问题: %s`
+
+// translationTargets 返回问题需要翻译成的语言：语料中除问题语言以外的语言。
+// 限定了文档语言（--doc-lang）时只翻译成该语言。
+func translationTargets(question string, languages []string, filterLang string) []string {
+	if filterLang != "" {
+		languages = []string{filterLang}
+	}
+	from := detectLanguage(question)
+	var targets []string
+	for _, lang := range languages {
+		if lang != "" && lang != from {
+			targets = append(targets, lang)
+		}
+	}
+	return targets
+}
+
+// translateVariants 把问题翻译成各目标语言，作为额外的检索变体。
+// llm方式失败时退回术语表；某个语言没有得到译文时跳过，返回第一个错误。
+// 只有配置错误时返回nil。
+func translateVariants(question string, targets []string, cfg CrossLingualConfig, generate func(prompt string) (string, error)) ([]queryVariant, error) {
+	if cfg.Method != translateLLM && cfg.Method != translateGlossary {
+		return nil, fmt.Errorf("unknown cross-lingual method %q", cfg.Method)
+	}
+	variants := []queryVariant{}
+	var firstErr error
+	for _, lang := range targets {
+		text := ""
+		if cfg.Method == translateLLM {
+			reply, err := generate(fmt.Sprintf(translatePrompt, languageName(lang), languageName(lang), question))
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("translating into %s: %w", lang, err)
+			}
+			text = cleanTranslation(reply)
+		}
+		if text == "" {
+			text = glossaryTranslate(question, detectLanguage(question), lang, cfg.Glossary)
+		}
+		if text == "" || strings.EqualFold(text, question) {
+			continue
+		}
+		variants = append(variants, queryVariant{Kind: "translation", Lang: lang, Text: text})
+	}
+	return variants, firstErr
+}
+
+// cleanTranslation 去掉模型译文中常见的多余内容：引号、"译文:"前缀和多余的行
+func cleanTranslation(reply string) string {
+	reply = strings.TrimSpace(reply)
+	if i := strings.IndexByte(reply, '\n'); i >= 0 {
+		reply = reply[:i]
+	}
+	for _, prefix := range []string{"译文:", "译文：", "Translation:"} {
+		reply = strings.TrimPrefix(reply, prefix)
+	}
+	return strings.Trim(strings.TrimSpace(reply), "\"'“”「」")
+}
+
+// glossaryTranslate 把问题中from语言的术语替换为to语言的对应术语。
+// 较长的术语优先替换；没有术语匹配时返回空串。
+func glossaryTranslate(question, from, to string, glossary []map[string]string) string {
+	type pair struct{ src, dst string }
+	var pairs []pair
+	for _, entry := range glossary {
+		if entry[from] != "" && entry[to] != "" {
+			pairs = append(pairs, pair{entry[from], entry[to]})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return len(pairs[i].src) > len(pairs[j].src)
+	})
+
+	text, matched := question, false
+	for _, p := range pairs {
+		re := glossaryPattern(p.src)
+		if re.MatchString(text) {
+			matched = true
+			text = re.ReplaceAllLiteralString(text, p.dst)
+		}
+	}
+	if !matched {
+		return ""
+	}
+	return text
+}
+
+// glossaryPattern 构造术语的匹配模式：忽略大小写，英文术语按整词匹配
+func glossaryPattern(term string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(term)
+	if isWordChar(term[0]) {
+		pattern = `\b` + pattern
+	}
+	if isWordChar(term[len(term)-1]) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile("(?i)" + pattern)
+}
+
+// isWordChar 判断字节是否为ASCII单词字符
+func isWordChar(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/rotemtam/entrag/ent"
+)
+
+var testGlossary = []map[string]string{
+	{"zh": "边", "en": "edges"},
+	{"zh": "产品数据管理", "en": "product data management"},
+	{"zh": "模式", "en": "schema"},
+}
+
+func TestGlossaryTranslate(t *testing.T) {
+	tests := []struct {
+		question, from, to, want string
+	}{
+		{"什么是模式的边？", "zh", "en", "什么是schema的edges？"},
+		{"What is Product Data Management?", "en", "zh", "What is 产品数据管理?"},
+		// 英文术语按整词匹配
+		{"How do schemata work?", "en", "zh", ""},
+		{"没有术语", "zh", "en", ""},
+	}
+	for _, tt := range tests {
+		if got := glossaryTranslate(tt.question, tt.from, tt.to, testGlossary); got != tt.want {
+			t.Errorf("glossaryTranslate(%q, %s→%s) = %q, want %q", tt.question, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestTranslationTargets(t *testing.T) {
+	languages := []string{"zh", "en"}
+	if got := translationTargets("什么是schema edges", languages, ""); len(got) != 1 || got[0] != "en" {
+		t.Errorf("zh question: got %v", got)
+	}
+	if got := translationTargets("What is PDM?", languages, ""); len(got) != 1 || got[0] != "zh" {
+		t.Errorf("en question: got %v", got)
+	}
+	if got := translationTargets("What is PDM?", languages, "en"); len(got) != 0 {
+		t.Errorf("doc-lang matching the question: got %v", got)
+	}
+}
+
+func TestTranslateVariants(t *testing.T) {
+	cfg := CrossLingualConfig{Method: translateLLM, Glossary: testGlossary}
+	generate := func(prompt string) (string, error) {
+		return "译文: \"What are schema edges?\"\n(translated)", nil
+	}
+	variants, err := translateVariants("什么是模式的边？", []string{"en"}, cfg, generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 1 || variants[0].Kind != "translation" || variants[0].Lang != "en" || variants[0].Text != "What are schema edges?" {
+		t.Errorf("llm translation: %+v", variants)
+	}
+
+	// 模型调用失败时退回术语表
+	failing := func(string) (string, error) { return "", errors.New("connection refused") }
+	variants, err = translateVariants("什么是模式的边？", []string{"en"}, cfg, failing)
+	if err == nil {
+		t.Error("expected the llm error to be reported")
+	}
+	if len(variants) != 1 || variants[0].Text != "什么是schema的edges？" {
+		t.Errorf("glossary fallback: %+v", variants)
+	}
+
+	if _, err := translateVariants("q", []string{"en"}, CrossLingualConfig{Method: "dictionary"}, failing); err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+}
+
+func TestFuseVariantsTranslationDistance(t *testing.T) {
+	zh := &ent.Chunk{ID: 1, Path: "data/cn/pdm.txt"}
+	emb := func(v float32) *ent.Embedding {
+		return &ent.Embedding{Embedding: pgvector.NewVector([]float32{v})}
+	}
+	variants := []queryVariant{{Kind: "original"}, {Kind: "translation", Lang: "zh"}}
+	lists := [][]*SearchHit{
+		nil,
+		{{Chunk: zh, Embedding: emb(5), Distance: 0.3, VectorRank: 1}},
+	}
+	fused := fuseVariants(variants, lists, []float32{1}, 60)
+	if len(fused) != 1 || math.Abs(fused[0].Distance-0.3) > 1e-9 {
+		t.Errorf("expected the distance to the translation, got %+v", fused)
+	}
+
+	// 其他变体召回的片段仍按原问题计算距离
+	variants[1].Kind = "paraphrase"
+	fused = fuseVariants(variants, lists, []float32{1}, 60)
+	if math.Abs(fused[0].Distance-4) > 1e-9 {
+		t.Errorf("expected the distance to the original question, got %v", fused[0].Distance)
+	}
+}
+
+func TestFuseVariantsFillsMissingEmbedding(t *testing.T) {
+	c := &ent.Chunk{ID: 1, Path: "data/ent/edges.md"}
+	e := &ent.Embedding{Embedding: pgvector.NewVector([]float32{3})}
+	variants := []queryVariant{{Kind: "original"}, {Kind: "paraphrase"}}
+	// 原问题只通过全文检索命中该片段，改写的问题通过向量检索命中
+	lists := [][]*SearchHit{
+		{{Chunk: c, TextRank: 1, Distance: math.Inf(1)}},
+		{{Chunk: c, Embedding: e, Distance: 0.5, VectorRank: 1}},
+	}
+	fused := fuseVariants(variants, lists, []float32{1}, 60)
+	if len(fused) != 1 || fused[0].Embedding != e {
+		t.Fatalf("expected the embedding of the later list, got %+v", fused)
+	}
+	if math.Abs(fused[0].Distance-2) > 1e-9 {
+		t.Errorf("expected the distance to the original question, got %v", fused[0].Distance)
+	}
+	if fused[0].TextRank != 1 || fused[0].VectorRank != 1 {
+		t.Errorf("unexpected ranks %+v", fused[0])
+	}
+}
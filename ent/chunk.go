@@ -3,6 +3,7 @@
 package ent
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -23,6 +24,20 @@ type Chunk struct {
 	Nchunk int `json:"nchunk,omitempty"`
 	// Data holds the value of the "data" field.
 	Data string `json:"data,omitempty"`
+	// Tsv holds the value of the "tsv" field.
+	Tsv string `json:"tsv,omitempty"`
+	// Title holds the value of the "title" field.
+	Title string `json:"title,omitempty"`
+	// Lang holds the value of the "lang" field.
+	Lang string `json:"lang,omitempty"`
+	// Heading holds the value of the "heading" field.
+	Heading string `json:"heading,omitempty"`
+	// StartLine holds the value of the "start_line" field.
+	StartLine int `json:"start_line,omitempty"`
+	// EndLine holds the value of the "end_line" field.
+	EndLine int `json:"end_line,omitempty"`
+	// Metadata holds the value of the "metadata" field.
+	Metadata map[string]string `json:"metadata,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the ChunkQuery when eager-loading is set.
 	Edges        ChunkEdges `json:"edges"`
@@ -54,9 +69,11 @@ func (*Chunk) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case chunk.FieldID, chunk.FieldNchunk:
+		case chunk.FieldMetadata:
+			values[i] = new([]byte)
+		case chunk.FieldID, chunk.FieldNchunk, chunk.FieldStartLine, chunk.FieldEndLine:
 			values[i] = new(sql.NullInt64)
-		case chunk.FieldPath, chunk.FieldData:
+		case chunk.FieldPath, chunk.FieldData, chunk.FieldTsv, chunk.FieldTitle, chunk.FieldLang, chunk.FieldHeading:
 			values[i] = new(sql.NullString)
 		default:
 			values[i] = new(sql.UnknownType)
@@ -97,6 +114,50 @@ func (c *Chunk) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				c.Data = value.String
 			}
+		case chunk.FieldTsv:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field tsv", values[i])
+			} else if value.Valid {
+				c.Tsv = value.String
+			}
+		case chunk.FieldTitle:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field title", values[i])
+			} else if value.Valid {
+				c.Title = value.String
+			}
+		case chunk.FieldLang:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field lang", values[i])
+			} else if value.Valid {
+				c.Lang = value.String
+			}
+		case chunk.FieldHeading:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field heading", values[i])
+			} else if value.Valid {
+				c.Heading = value.String
+			}
+		case chunk.FieldStartLine:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field start_line", values[i])
+			} else if value.Valid {
+				c.StartLine = int(value.Int64)
+			}
+		case chunk.FieldEndLine:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field end_line", values[i])
+			} else if value.Valid {
+				c.EndLine = int(value.Int64)
+			}
+		case chunk.FieldMetadata:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field metadata", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &c.Metadata); err != nil {
+					return fmt.Errorf("unmarshal field metadata: %w", err)
+				}
+			}
 		default:
 			c.selectValues.Set(columns[i], values[i])
 		}
@@ -146,6 +207,27 @@ func (c *Chunk) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("data=")
 	builder.WriteString(c.Data)
+	builder.WriteString(", ")
+	builder.WriteString("tsv=")
+	builder.WriteString(c.Tsv)
+	builder.WriteString(", ")
+	builder.WriteString("title=")
+	builder.WriteString(c.Title)
+	builder.WriteString(", ")
+	builder.WriteString("lang=")
+	builder.WriteString(c.Lang)
+	builder.WriteString(", ")
+	builder.WriteString("heading=")
+	builder.WriteString(c.Heading)
+	builder.WriteString(", ")
+	builder.WriteString("start_line=")
+	builder.WriteString(fmt.Sprintf("%v", c.StartLine))
+	builder.WriteString(", ")
+	builder.WriteString("end_line=")
+	builder.WriteString(fmt.Sprintf("%v", c.EndLine))
+	builder.WriteString(", ")
+	builder.WriteString("metadata=")
+	builder.WriteString(fmt.Sprintf("%v", c.Metadata))
 	builder.WriteByte(')')
 	return builder.String()
 }
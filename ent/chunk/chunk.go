@@ -18,6 +18,20 @@ const (
 	FieldNchunk = "nchunk"
 	// FieldData holds the string denoting the data field in the database.
 	FieldData = "data"
+	// FieldTsv holds the string denoting the tsv field in the database.
+	FieldTsv = "tsv"
+	// FieldTitle holds the string denoting the title field in the database.
+	FieldTitle = "title"
+	// FieldLang holds the string denoting the lang field in the database.
+	FieldLang = "lang"
+	// FieldHeading holds the string denoting the heading field in the database.
+	FieldHeading = "heading"
+	// FieldStartLine holds the string denoting the start_line field in the database.
+	FieldStartLine = "start_line"
+	// FieldEndLine holds the string denoting the end_line field in the database.
+	FieldEndLine = "end_line"
+	// FieldMetadata holds the string denoting the metadata field in the database.
+	FieldMetadata = "metadata"
 	// EdgeEmbedding holds the string denoting the embedding edge name in mutations.
 	EdgeEmbedding = "embedding"
 	// Table holds the table name of the chunk in the database.
@@ -37,6 +51,13 @@ var Columns = []string{
 	FieldPath,
 	FieldNchunk,
 	FieldData,
+	FieldTsv,
+	FieldTitle,
+	FieldLang,
+	FieldHeading,
+	FieldStartLine,
+	FieldEndLine,
+	FieldMetadata,
 }
 
 // ValidColumn reports if the column name is valid (part of the table columns).
@@ -72,6 +93,36 @@ func ByData(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldData, opts...).ToFunc()
 }
 
+// ByTsv orders the results by the tsv field.
+func ByTsv(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldTsv, opts...).ToFunc()
+}
+
+// ByTitle orders the results by the title field.
+func ByTitle(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldTitle, opts...).ToFunc()
+}
+
+// ByLang orders the results by the lang field.
+func ByLang(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldLang, opts...).ToFunc()
+}
+
+// ByHeading orders the results by the heading field.
+func ByHeading(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldHeading, opts...).ToFunc()
+}
+
+// ByStartLine orders the results by the start_line field.
+func ByStartLine(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldStartLine, opts...).ToFunc()
+}
+
+// ByEndLine orders the results by the end_line field.
+func ByEndLine(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldEndLine, opts...).ToFunc()
+}
+
 // ByEmbeddingField orders the results by embedding field.
 func ByEmbeddingField(field string, opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -68,6 +68,36 @@ func Data(v string) predicate.Chunk {
 	return predicate.Chunk(sql.FieldEQ(FieldData, v))
 }
 
+// Tsv applies equality check predicate on the "tsv" field. It's identical to TsvEQ.
+func Tsv(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldTsv, v))
+}
+
+// Title applies equality check predicate on the "title" field. It's identical to TitleEQ.
+func Title(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldTitle, v))
+}
+
+// Lang applies equality check predicate on the "lang" field. It's identical to LangEQ.
+func Lang(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldLang, v))
+}
+
+// Heading applies equality check predicate on the "heading" field. It's identical to HeadingEQ.
+func Heading(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldHeading, v))
+}
+
+// StartLine applies equality check predicate on the "start_line" field. It's identical to StartLineEQ.
+func StartLine(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldStartLine, v))
+}
+
+// EndLine applies equality check predicate on the "end_line" field. It's identical to EndLineEQ.
+func EndLine(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldEndLine, v))
+}
+
 // PathEQ applies the EQ predicate on the "path" field.
 func PathEQ(v string) predicate.Chunk {
 	return predicate.Chunk(sql.FieldEQ(FieldPath, v))
@@ -238,6 +268,416 @@ func DataContainsFold(v string) predicate.Chunk {
 	return predicate.Chunk(sql.FieldContainsFold(FieldData, v))
 }
 
+// TsvEQ applies the EQ predicate on the "tsv" field.
+func TsvEQ(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldTsv, v))
+}
+
+// TsvNEQ applies the NEQ predicate on the "tsv" field.
+func TsvNEQ(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNEQ(FieldTsv, v))
+}
+
+// TsvIn applies the In predicate on the "tsv" field.
+func TsvIn(vs ...string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldIn(FieldTsv, vs...))
+}
+
+// TsvNotIn applies the NotIn predicate on the "tsv" field.
+func TsvNotIn(vs ...string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotIn(FieldTsv, vs...))
+}
+
+// TsvGT applies the GT predicate on the "tsv" field.
+func TsvGT(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGT(FieldTsv, v))
+}
+
+// TsvGTE applies the GTE predicate on the "tsv" field.
+func TsvGTE(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGTE(FieldTsv, v))
+}
+
+// TsvLT applies the LT predicate on the "tsv" field.
+func TsvLT(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLT(FieldTsv, v))
+}
+
+// TsvLTE applies the LTE predicate on the "tsv" field.
+func TsvLTE(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLTE(FieldTsv, v))
+}
+
+// TsvContains applies the Contains predicate on the "tsv" field.
+func TsvContains(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldContains(FieldTsv, v))
+}
+
+// TsvHasPrefix applies the HasPrefix predicate on the "tsv" field.
+func TsvHasPrefix(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldHasPrefix(FieldTsv, v))
+}
+
+// TsvHasSuffix applies the HasSuffix predicate on the "tsv" field.
+func TsvHasSuffix(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldHasSuffix(FieldTsv, v))
+}
+
+// TsvIsNil applies the IsNil predicate on the "tsv" field.
+func TsvIsNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldIsNull(FieldTsv))
+}
+
+// TsvNotNil applies the NotNil predicate on the "tsv" field.
+func TsvNotNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotNull(FieldTsv))
+}
+
+// TsvEqualFold applies the EqualFold predicate on the "tsv" field.
+func TsvEqualFold(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEqualFold(FieldTsv, v))
+}
+
+// TsvContainsFold applies the ContainsFold predicate on the "tsv" field.
+func TsvContainsFold(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldContainsFold(FieldTsv, v))
+}
+
+// TitleEQ applies the EQ predicate on the "title" field.
+func TitleEQ(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldTitle, v))
+}
+
+// TitleNEQ applies the NEQ predicate on the "title" field.
+func TitleNEQ(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNEQ(FieldTitle, v))
+}
+
+// TitleIn applies the In predicate on the "title" field.
+func TitleIn(vs ...string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldIn(FieldTitle, vs...))
+}
+
+// TitleNotIn applies the NotIn predicate on the "title" field.
+func TitleNotIn(vs ...string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotIn(FieldTitle, vs...))
+}
+
+// TitleGT applies the GT predicate on the "title" field.
+func TitleGT(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGT(FieldTitle, v))
+}
+
+// TitleGTE applies the GTE predicate on the "title" field.
+func TitleGTE(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGTE(FieldTitle, v))
+}
+
+// TitleLT applies the LT predicate on the "title" field.
+func TitleLT(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLT(FieldTitle, v))
+}
+
+// TitleLTE applies the LTE predicate on the "title" field.
+func TitleLTE(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLTE(FieldTitle, v))
+}
+
+// TitleContains applies the Contains predicate on the "title" field.
+func TitleContains(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldContains(FieldTitle, v))
+}
+
+// TitleHasPrefix applies the HasPrefix predicate on the "title" field.
+func TitleHasPrefix(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldHasPrefix(FieldTitle, v))
+}
+
+// TitleHasSuffix applies the HasSuffix predicate on the "title" field.
+func TitleHasSuffix(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldHasSuffix(FieldTitle, v))
+}
+
+// TitleIsNil applies the IsNil predicate on the "title" field.
+func TitleIsNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldIsNull(FieldTitle))
+}
+
+// TitleNotNil applies the NotNil predicate on the "title" field.
+func TitleNotNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotNull(FieldTitle))
+}
+
+// TitleEqualFold applies the EqualFold predicate on the "title" field.
+func TitleEqualFold(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEqualFold(FieldTitle, v))
+}
+
+// TitleContainsFold applies the ContainsFold predicate on the "title" field.
+func TitleContainsFold(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldContainsFold(FieldTitle, v))
+}
+
+// LangEQ applies the EQ predicate on the "lang" field.
+func LangEQ(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldLang, v))
+}
+
+// LangNEQ applies the NEQ predicate on the "lang" field.
+func LangNEQ(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNEQ(FieldLang, v))
+}
+
+// LangIn applies the In predicate on the "lang" field.
+func LangIn(vs ...string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldIn(FieldLang, vs...))
+}
+
+// LangNotIn applies the NotIn predicate on the "lang" field.
+func LangNotIn(vs ...string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotIn(FieldLang, vs...))
+}
+
+// LangGT applies the GT predicate on the "lang" field.
+func LangGT(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGT(FieldLang, v))
+}
+
+// LangGTE applies the GTE predicate on the "lang" field.
+func LangGTE(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGTE(FieldLang, v))
+}
+
+// LangLT applies the LT predicate on the "lang" field.
+func LangLT(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLT(FieldLang, v))
+}
+
+// LangLTE applies the LTE predicate on the "lang" field.
+func LangLTE(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLTE(FieldLang, v))
+}
+
+// LangContains applies the Contains predicate on the "lang" field.
+func LangContains(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldContains(FieldLang, v))
+}
+
+// LangHasPrefix applies the HasPrefix predicate on the "lang" field.
+func LangHasPrefix(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldHasPrefix(FieldLang, v))
+}
+
+// LangHasSuffix applies the HasSuffix predicate on the "lang" field.
+func LangHasSuffix(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldHasSuffix(FieldLang, v))
+}
+
+// LangIsNil applies the IsNil predicate on the "lang" field.
+func LangIsNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldIsNull(FieldLang))
+}
+
+// LangNotNil applies the NotNil predicate on the "lang" field.
+func LangNotNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotNull(FieldLang))
+}
+
+// LangEqualFold applies the EqualFold predicate on the "lang" field.
+func LangEqualFold(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEqualFold(FieldLang, v))
+}
+
+// LangContainsFold applies the ContainsFold predicate on the "lang" field.
+func LangContainsFold(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldContainsFold(FieldLang, v))
+}
+
+// HeadingEQ applies the EQ predicate on the "heading" field.
+func HeadingEQ(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldHeading, v))
+}
+
+// HeadingNEQ applies the NEQ predicate on the "heading" field.
+func HeadingNEQ(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNEQ(FieldHeading, v))
+}
+
+// HeadingIn applies the In predicate on the "heading" field.
+func HeadingIn(vs ...string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldIn(FieldHeading, vs...))
+}
+
+// HeadingNotIn applies the NotIn predicate on the "heading" field.
+func HeadingNotIn(vs ...string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotIn(FieldHeading, vs...))
+}
+
+// HeadingGT applies the GT predicate on the "heading" field.
+func HeadingGT(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGT(FieldHeading, v))
+}
+
+// HeadingGTE applies the GTE predicate on the "heading" field.
+func HeadingGTE(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGTE(FieldHeading, v))
+}
+
+// HeadingLT applies the LT predicate on the "heading" field.
+func HeadingLT(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLT(FieldHeading, v))
+}
+
+// HeadingLTE applies the LTE predicate on the "heading" field.
+func HeadingLTE(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLTE(FieldHeading, v))
+}
+
+// HeadingContains applies the Contains predicate on the "heading" field.
+func HeadingContains(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldContains(FieldHeading, v))
+}
+
+// HeadingHasPrefix applies the HasPrefix predicate on the "heading" field.
+func HeadingHasPrefix(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldHasPrefix(FieldHeading, v))
+}
+
+// HeadingHasSuffix applies the HasSuffix predicate on the "heading" field.
+func HeadingHasSuffix(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldHasSuffix(FieldHeading, v))
+}
+
+// HeadingIsNil applies the IsNil predicate on the "heading" field.
+func HeadingIsNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldIsNull(FieldHeading))
+}
+
+// HeadingNotNil applies the NotNil predicate on the "heading" field.
+func HeadingNotNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotNull(FieldHeading))
+}
+
+// HeadingEqualFold applies the EqualFold predicate on the "heading" field.
+func HeadingEqualFold(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEqualFold(FieldHeading, v))
+}
+
+// HeadingContainsFold applies the ContainsFold predicate on the "heading" field.
+func HeadingContainsFold(v string) predicate.Chunk {
+	return predicate.Chunk(sql.FieldContainsFold(FieldHeading, v))
+}
+
+// StartLineEQ applies the EQ predicate on the "start_line" field.
+func StartLineEQ(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldStartLine, v))
+}
+
+// StartLineNEQ applies the NEQ predicate on the "start_line" field.
+func StartLineNEQ(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNEQ(FieldStartLine, v))
+}
+
+// StartLineIn applies the In predicate on the "start_line" field.
+func StartLineIn(vs ...int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldIn(FieldStartLine, vs...))
+}
+
+// StartLineNotIn applies the NotIn predicate on the "start_line" field.
+func StartLineNotIn(vs ...int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotIn(FieldStartLine, vs...))
+}
+
+// StartLineGT applies the GT predicate on the "start_line" field.
+func StartLineGT(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGT(FieldStartLine, v))
+}
+
+// StartLineGTE applies the GTE predicate on the "start_line" field.
+func StartLineGTE(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGTE(FieldStartLine, v))
+}
+
+// StartLineLT applies the LT predicate on the "start_line" field.
+func StartLineLT(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLT(FieldStartLine, v))
+}
+
+// StartLineLTE applies the LTE predicate on the "start_line" field.
+func StartLineLTE(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLTE(FieldStartLine, v))
+}
+
+// StartLineIsNil applies the IsNil predicate on the "start_line" field.
+func StartLineIsNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldIsNull(FieldStartLine))
+}
+
+// StartLineNotNil applies the NotNil predicate on the "start_line" field.
+func StartLineNotNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotNull(FieldStartLine))
+}
+
+// EndLineEQ applies the EQ predicate on the "end_line" field.
+func EndLineEQ(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldEQ(FieldEndLine, v))
+}
+
+// EndLineNEQ applies the NEQ predicate on the "end_line" field.
+func EndLineNEQ(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNEQ(FieldEndLine, v))
+}
+
+// EndLineIn applies the In predicate on the "end_line" field.
+func EndLineIn(vs ...int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldIn(FieldEndLine, vs...))
+}
+
+// EndLineNotIn applies the NotIn predicate on the "end_line" field.
+func EndLineNotIn(vs ...int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotIn(FieldEndLine, vs...))
+}
+
+// EndLineGT applies the GT predicate on the "end_line" field.
+func EndLineGT(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGT(FieldEndLine, v))
+}
+
+// EndLineGTE applies the GTE predicate on the "end_line" field.
+func EndLineGTE(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldGTE(FieldEndLine, v))
+}
+
+// EndLineLT applies the LT predicate on the "end_line" field.
+func EndLineLT(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLT(FieldEndLine, v))
+}
+
+// EndLineLTE applies the LTE predicate on the "end_line" field.
+func EndLineLTE(v int) predicate.Chunk {
+	return predicate.Chunk(sql.FieldLTE(FieldEndLine, v))
+}
+
+// EndLineIsNil applies the IsNil predicate on the "end_line" field.
+func EndLineIsNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldIsNull(FieldEndLine))
+}
+
+// EndLineNotNil applies the NotNil predicate on the "end_line" field.
+func EndLineNotNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotNull(FieldEndLine))
+}
+
+// MetadataIsNil applies the IsNil predicate on the "metadata" field.
+func MetadataIsNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldIsNull(FieldMetadata))
+}
+
+// MetadataNotNil applies the NotNil predicate on the "metadata" field.
+func MetadataNotNil() predicate.Chunk {
+	return predicate.Chunk(sql.FieldNotNull(FieldMetadata))
+}
+
 // HasEmbedding applies the HasEdge predicate on the "embedding" edge.
 func HasEmbedding() predicate.Chunk {
 	return predicate.Chunk(func(s *sql.Selector) {
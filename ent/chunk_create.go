@@ -38,6 +38,96 @@ func (cc *ChunkCreate) SetData(s string) *ChunkCreate {
 	return cc
 }
 
+// SetTsv sets the "tsv" field.
+func (cc *ChunkCreate) SetTsv(s string) *ChunkCreate {
+	cc.mutation.SetTsv(s)
+	return cc
+}
+
+// SetNillableTsv sets the "tsv" field if the given value is not nil.
+func (cc *ChunkCreate) SetNillableTsv(s *string) *ChunkCreate {
+	if s != nil {
+		cc.SetTsv(*s)
+	}
+	return cc
+}
+
+// SetTitle sets the "title" field.
+func (cc *ChunkCreate) SetTitle(s string) *ChunkCreate {
+	cc.mutation.SetTitle(s)
+	return cc
+}
+
+// SetNillableTitle sets the "title" field if the given value is not nil.
+func (cc *ChunkCreate) SetNillableTitle(s *string) *ChunkCreate {
+	if s != nil {
+		cc.SetTitle(*s)
+	}
+	return cc
+}
+
+// SetLang sets the "lang" field.
+func (cc *ChunkCreate) SetLang(s string) *ChunkCreate {
+	cc.mutation.SetLang(s)
+	return cc
+}
+
+// SetNillableLang sets the "lang" field if the given value is not nil.
+func (cc *ChunkCreate) SetNillableLang(s *string) *ChunkCreate {
+	if s != nil {
+		cc.SetLang(*s)
+	}
+	return cc
+}
+
+// SetHeading sets the "heading" field.
+func (cc *ChunkCreate) SetHeading(s string) *ChunkCreate {
+	cc.mutation.SetHeading(s)
+	return cc
+}
+
+// SetNillableHeading sets the "heading" field if the given value is not nil.
+func (cc *ChunkCreate) SetNillableHeading(s *string) *ChunkCreate {
+	if s != nil {
+		cc.SetHeading(*s)
+	}
+	return cc
+}
+
+// SetStartLine sets the "start_line" field.
+func (cc *ChunkCreate) SetStartLine(i int) *ChunkCreate {
+	cc.mutation.SetStartLine(i)
+	return cc
+}
+
+// SetNillableStartLine sets the "start_line" field if the given value is not nil.
+func (cc *ChunkCreate) SetNillableStartLine(i *int) *ChunkCreate {
+	if i != nil {
+		cc.SetStartLine(*i)
+	}
+	return cc
+}
+
+// SetEndLine sets the "end_line" field.
+func (cc *ChunkCreate) SetEndLine(i int) *ChunkCreate {
+	cc.mutation.SetEndLine(i)
+	return cc
+}
+
+// SetNillableEndLine sets the "end_line" field if the given value is not nil.
+func (cc *ChunkCreate) SetNillableEndLine(i *int) *ChunkCreate {
+	if i != nil {
+		cc.SetEndLine(*i)
+	}
+	return cc
+}
+
+// SetMetadata sets the "metadata" field.
+func (cc *ChunkCreate) SetMetadata(m map[string]string) *ChunkCreate {
+	cc.mutation.SetMetadata(m)
+	return cc
+}
+
 // SetEmbeddingID sets the "embedding" edge to the Embedding entity by ID.
 func (cc *ChunkCreate) SetEmbeddingID(id int) *ChunkCreate {
 	cc.mutation.SetEmbeddingID(id)
@@ -138,6 +228,34 @@ func (cc *ChunkCreate) createSpec() (*Chunk, *sqlgraph.CreateSpec) {
 		_spec.SetField(chunk.FieldData, field.TypeString, value)
 		_node.Data = value
 	}
+	if value, ok := cc.mutation.Tsv(); ok {
+		_spec.SetField(chunk.FieldTsv, field.TypeString, value)
+		_node.Tsv = value
+	}
+	if value, ok := cc.mutation.Title(); ok {
+		_spec.SetField(chunk.FieldTitle, field.TypeString, value)
+		_node.Title = value
+	}
+	if value, ok := cc.mutation.Lang(); ok {
+		_spec.SetField(chunk.FieldLang, field.TypeString, value)
+		_node.Lang = value
+	}
+	if value, ok := cc.mutation.Heading(); ok {
+		_spec.SetField(chunk.FieldHeading, field.TypeString, value)
+		_node.Heading = value
+	}
+	if value, ok := cc.mutation.StartLine(); ok {
+		_spec.SetField(chunk.FieldStartLine, field.TypeInt, value)
+		_node.StartLine = value
+	}
+	if value, ok := cc.mutation.EndLine(); ok {
+		_spec.SetField(chunk.FieldEndLine, field.TypeInt, value)
+		_node.EndLine = value
+	}
+	if value, ok := cc.mutation.Metadata(); ok {
+		_spec.SetField(chunk.FieldMetadata, field.TypeJSON, value)
+		_node.Metadata = value
+	}
 	if nodes := cc.mutation.EmbeddingIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2O,
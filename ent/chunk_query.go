@@ -25,6 +25,7 @@ type ChunkQuery struct {
 	inters        []Interceptor
 	predicates    []predicate.Chunk
 	withEmbedding *EmbeddingQuery
+	modifiers     []func(*sql.Selector)
 	// intermediate query (i.e. traversal path).
 	sql  *sql.Selector
 	path func(context.Context) (*sql.Selector, error)
@@ -277,8 +278,9 @@ func (cq *ChunkQuery) Clone() *ChunkQuery {
 		predicates:    append([]predicate.Chunk{}, cq.predicates...),
 		withEmbedding: cq.withEmbedding.Clone(),
 		// clone intermediate query.
-		sql:  cq.sql.Clone(),
-		path: cq.path,
+		sql:       cq.sql.Clone(),
+		path:      cq.path,
+		modifiers: append([]func(*sql.Selector){}, cq.modifiers...),
 	}
 }
 
@@ -384,6 +386,9 @@ func (cq *ChunkQuery) sqlAll(ctx context.Context, hooks ...queryHook) ([]*Chunk,
 		node.Edges.loadedTypes = loadedTypes
 		return node.assignValues(columns, values)
 	}
+	if len(cq.modifiers) > 0 {
+		_spec.Modifiers = cq.modifiers
+	}
 	for i := range hooks {
 		hooks[i](ctx, _spec)
 	}
@@ -433,6 +438,9 @@ func (cq *ChunkQuery) loadEmbedding(ctx context.Context, query *EmbeddingQuery,
 
 func (cq *ChunkQuery) sqlCount(ctx context.Context) (int, error) {
 	_spec := cq.querySpec()
+	if len(cq.modifiers) > 0 {
+		_spec.Modifiers = cq.modifiers
+	}
 	_spec.Node.Columns = cq.ctx.Fields
 	if len(cq.ctx.Fields) > 0 {
 		_spec.Unique = cq.ctx.Unique != nil && *cq.ctx.Unique
@@ -495,6 +503,9 @@ func (cq *ChunkQuery) sqlQuery(ctx context.Context) *sql.Selector {
 	if cq.ctx.Unique != nil && *cq.ctx.Unique {
 		selector.Distinct()
 	}
+	for _, m := range cq.modifiers {
+		m(selector)
+	}
 	for _, p := range cq.predicates {
 		p(selector)
 	}
@@ -512,6 +523,12 @@ func (cq *ChunkQuery) sqlQuery(ctx context.Context) *sql.Selector {
 	return selector
 }
 
+// Modify adds a query modifier for attaching custom logic to queries.
+func (cq *ChunkQuery) Modify(modifiers ...func(s *sql.Selector)) *ChunkSelect {
+	cq.modifiers = append(cq.modifiers, modifiers...)
+	return cq.Select()
+}
+
 // ChunkGroupBy is the group-by builder for Chunk entities.
 type ChunkGroupBy struct {
 	selector
@@ -601,3 +618,9 @@ func (cs *ChunkSelect) sqlScan(ctx context.Context, root *ChunkQuery, v any) err
 	defer rows.Close()
 	return sql.ScanSlice(rows, v)
 }
+
+// Modify adds a query modifier for attaching custom logic to queries.
+func (cs *ChunkSelect) Modify(modifiers ...func(s *sql.Selector)) *ChunkSelect {
+	cs.modifiers = append(cs.modifiers, modifiers...)
+	return cs
+}
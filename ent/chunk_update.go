@@ -18,8 +18,9 @@ import (
 // ChunkUpdate is the builder for updating Chunk entities.
 type ChunkUpdate struct {
 	config
-	hooks    []Hook
-	mutation *ChunkMutation
+	hooks     []Hook
+	mutation  *ChunkMutation
+	modifiers []func(*sql.UpdateBuilder)
 }
 
 // Where appends a list predicates to the ChunkUpdate builder.
@@ -77,6 +78,152 @@ func (cu *ChunkUpdate) SetNillableData(s *string) *ChunkUpdate {
 	return cu
 }
 
+// SetTsv sets the "tsv" field.
+func (cu *ChunkUpdate) SetTsv(s string) *ChunkUpdate {
+	cu.mutation.SetTsv(s)
+	return cu
+}
+
+// SetNillableTsv sets the "tsv" field if the given value is not nil.
+func (cu *ChunkUpdate) SetNillableTsv(s *string) *ChunkUpdate {
+	if s != nil {
+		cu.SetTsv(*s)
+	}
+	return cu
+}
+
+// ClearTsv clears the value of the "tsv" field.
+func (cu *ChunkUpdate) ClearTsv() *ChunkUpdate {
+	cu.mutation.ClearTsv()
+	return cu
+}
+
+// SetTitle sets the "title" field.
+func (cu *ChunkUpdate) SetTitle(s string) *ChunkUpdate {
+	cu.mutation.SetTitle(s)
+	return cu
+}
+
+// SetNillableTitle sets the "title" field if the given value is not nil.
+func (cu *ChunkUpdate) SetNillableTitle(s *string) *ChunkUpdate {
+	if s != nil {
+		cu.SetTitle(*s)
+	}
+	return cu
+}
+
+// ClearTitle clears the value of the "title" field.
+func (cu *ChunkUpdate) ClearTitle() *ChunkUpdate {
+	cu.mutation.ClearTitle()
+	return cu
+}
+
+// SetLang sets the "lang" field.
+func (cu *ChunkUpdate) SetLang(s string) *ChunkUpdate {
+	cu.mutation.SetLang(s)
+	return cu
+}
+
+// SetNillableLang sets the "lang" field if the given value is not nil.
+func (cu *ChunkUpdate) SetNillableLang(s *string) *ChunkUpdate {
+	if s != nil {
+		cu.SetLang(*s)
+	}
+	return cu
+}
+
+// ClearLang clears the value of the "lang" field.
+func (cu *ChunkUpdate) ClearLang() *ChunkUpdate {
+	cu.mutation.ClearLang()
+	return cu
+}
+
+// SetHeading sets the "heading" field.
+func (cu *ChunkUpdate) SetHeading(s string) *ChunkUpdate {
+	cu.mutation.SetHeading(s)
+	return cu
+}
+
+// SetNillableHeading sets the "heading" field if the given value is not nil.
+func (cu *ChunkUpdate) SetNillableHeading(s *string) *ChunkUpdate {
+	if s != nil {
+		cu.SetHeading(*s)
+	}
+	return cu
+}
+
+// ClearHeading clears the value of the "heading" field.
+func (cu *ChunkUpdate) ClearHeading() *ChunkUpdate {
+	cu.mutation.ClearHeading()
+	return cu
+}
+
+// SetStartLine sets the "start_line" field.
+func (cu *ChunkUpdate) SetStartLine(i int) *ChunkUpdate {
+	cu.mutation.ResetStartLine()
+	cu.mutation.SetStartLine(i)
+	return cu
+}
+
+// SetNillableStartLine sets the "start_line" field if the given value is not nil.
+func (cu *ChunkUpdate) SetNillableStartLine(i *int) *ChunkUpdate {
+	if i != nil {
+		cu.SetStartLine(*i)
+	}
+	return cu
+}
+
+// AddStartLine adds i to the "start_line" field.
+func (cu *ChunkUpdate) AddStartLine(i int) *ChunkUpdate {
+	cu.mutation.AddStartLine(i)
+	return cu
+}
+
+// ClearStartLine clears the value of the "start_line" field.
+func (cu *ChunkUpdate) ClearStartLine() *ChunkUpdate {
+	cu.mutation.ClearStartLine()
+	return cu
+}
+
+// SetEndLine sets the "end_line" field.
+func (cu *ChunkUpdate) SetEndLine(i int) *ChunkUpdate {
+	cu.mutation.ResetEndLine()
+	cu.mutation.SetEndLine(i)
+	return cu
+}
+
+// SetNillableEndLine sets the "end_line" field if the given value is not nil.
+func (cu *ChunkUpdate) SetNillableEndLine(i *int) *ChunkUpdate {
+	if i != nil {
+		cu.SetEndLine(*i)
+	}
+	return cu
+}
+
+// AddEndLine adds i to the "end_line" field.
+func (cu *ChunkUpdate) AddEndLine(i int) *ChunkUpdate {
+	cu.mutation.AddEndLine(i)
+	return cu
+}
+
+// ClearEndLine clears the value of the "end_line" field.
+func (cu *ChunkUpdate) ClearEndLine() *ChunkUpdate {
+	cu.mutation.ClearEndLine()
+	return cu
+}
+
+// SetMetadata sets the "metadata" field.
+func (cu *ChunkUpdate) SetMetadata(m map[string]string) *ChunkUpdate {
+	cu.mutation.SetMetadata(m)
+	return cu
+}
+
+// ClearMetadata clears the value of the "metadata" field.
+func (cu *ChunkUpdate) ClearMetadata() *ChunkUpdate {
+	cu.mutation.ClearMetadata()
+	return cu
+}
+
 // SetEmbeddingID sets the "embedding" edge to the Embedding entity by ID.
 func (cu *ChunkUpdate) SetEmbeddingID(id int) *ChunkUpdate {
 	cu.mutation.SetEmbeddingID(id)
@@ -134,6 +281,12 @@ func (cu *ChunkUpdate) ExecX(ctx context.Context) {
 	}
 }
 
+// Modify adds a statement modifier for attaching custom logic to the UPDATE statement.
+func (cu *ChunkUpdate) Modify(modifiers ...func(u *sql.UpdateBuilder)) *ChunkUpdate {
+	cu.modifiers = append(cu.modifiers, modifiers...)
+	return cu
+}
+
 func (cu *ChunkUpdate) sqlSave(ctx context.Context) (n int, err error) {
 	_spec := sqlgraph.NewUpdateSpec(chunk.Table, chunk.Columns, sqlgraph.NewFieldSpec(chunk.FieldID, field.TypeInt))
 	if ps := cu.mutation.predicates; len(ps) > 0 {
@@ -155,6 +308,54 @@ func (cu *ChunkUpdate) sqlSave(ctx context.Context) (n int, err error) {
 	if value, ok := cu.mutation.Data(); ok {
 		_spec.SetField(chunk.FieldData, field.TypeString, value)
 	}
+	if value, ok := cu.mutation.Tsv(); ok {
+		_spec.SetField(chunk.FieldTsv, field.TypeString, value)
+	}
+	if cu.mutation.TsvCleared() {
+		_spec.ClearField(chunk.FieldTsv, field.TypeString)
+	}
+	if value, ok := cu.mutation.Title(); ok {
+		_spec.SetField(chunk.FieldTitle, field.TypeString, value)
+	}
+	if cu.mutation.TitleCleared() {
+		_spec.ClearField(chunk.FieldTitle, field.TypeString)
+	}
+	if value, ok := cu.mutation.Lang(); ok {
+		_spec.SetField(chunk.FieldLang, field.TypeString, value)
+	}
+	if cu.mutation.LangCleared() {
+		_spec.ClearField(chunk.FieldLang, field.TypeString)
+	}
+	if value, ok := cu.mutation.Heading(); ok {
+		_spec.SetField(chunk.FieldHeading, field.TypeString, value)
+	}
+	if cu.mutation.HeadingCleared() {
+		_spec.ClearField(chunk.FieldHeading, field.TypeString)
+	}
+	if value, ok := cu.mutation.StartLine(); ok {
+		_spec.SetField(chunk.FieldStartLine, field.TypeInt, value)
+	}
+	if value, ok := cu.mutation.AddedStartLine(); ok {
+		_spec.AddField(chunk.FieldStartLine, field.TypeInt, value)
+	}
+	if cu.mutation.StartLineCleared() {
+		_spec.ClearField(chunk.FieldStartLine, field.TypeInt)
+	}
+	if value, ok := cu.mutation.EndLine(); ok {
+		_spec.SetField(chunk.FieldEndLine, field.TypeInt, value)
+	}
+	if value, ok := cu.mutation.AddedEndLine(); ok {
+		_spec.AddField(chunk.FieldEndLine, field.TypeInt, value)
+	}
+	if cu.mutation.EndLineCleared() {
+		_spec.ClearField(chunk.FieldEndLine, field.TypeInt)
+	}
+	if value, ok := cu.mutation.Metadata(); ok {
+		_spec.SetField(chunk.FieldMetadata, field.TypeJSON, value)
+	}
+	if cu.mutation.MetadataCleared() {
+		_spec.ClearField(chunk.FieldMetadata, field.TypeJSON)
+	}
 	if cu.mutation.EmbeddingCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2O,
@@ -184,6 +385,7 @@ func (cu *ChunkUpdate) sqlSave(ctx context.Context) (n int, err error) {
 		}
 		_spec.Edges.Add = append(_spec.Edges.Add, edge)
 	}
+	_spec.AddModifiers(cu.modifiers...)
 	if n, err = sqlgraph.UpdateNodes(ctx, cu.driver, _spec); err != nil {
 		if _, ok := err.(*sqlgraph.NotFoundError); ok {
 			err = &NotFoundError{chunk.Label}
@@ -199,9 +401,10 @@ func (cu *ChunkUpdate) sqlSave(ctx context.Context) (n int, err error) {
 // ChunkUpdateOne is the builder for updating a single Chunk entity.
 type ChunkUpdateOne struct {
 	config
-	fields   []string
-	hooks    []Hook
-	mutation *ChunkMutation
+	fields    []string
+	hooks     []Hook
+	mutation  *ChunkMutation
+	modifiers []func(*sql.UpdateBuilder)
 }
 
 // SetPath sets the "path" field.
@@ -253,6 +456,152 @@ func (cuo *ChunkUpdateOne) SetNillableData(s *string) *ChunkUpdateOne {
 	return cuo
 }
 
+// SetTsv sets the "tsv" field.
+func (cuo *ChunkUpdateOne) SetTsv(s string) *ChunkUpdateOne {
+	cuo.mutation.SetTsv(s)
+	return cuo
+}
+
+// SetNillableTsv sets the "tsv" field if the given value is not nil.
+func (cuo *ChunkUpdateOne) SetNillableTsv(s *string) *ChunkUpdateOne {
+	if s != nil {
+		cuo.SetTsv(*s)
+	}
+	return cuo
+}
+
+// ClearTsv clears the value of the "tsv" field.
+func (cuo *ChunkUpdateOne) ClearTsv() *ChunkUpdateOne {
+	cuo.mutation.ClearTsv()
+	return cuo
+}
+
+// SetTitle sets the "title" field.
+func (cuo *ChunkUpdateOne) SetTitle(s string) *ChunkUpdateOne {
+	cuo.mutation.SetTitle(s)
+	return cuo
+}
+
+// SetNillableTitle sets the "title" field if the given value is not nil.
+func (cuo *ChunkUpdateOne) SetNillableTitle(s *string) *ChunkUpdateOne {
+	if s != nil {
+		cuo.SetTitle(*s)
+	}
+	return cuo
+}
+
+// ClearTitle clears the value of the "title" field.
+func (cuo *ChunkUpdateOne) ClearTitle() *ChunkUpdateOne {
+	cuo.mutation.ClearTitle()
+	return cuo
+}
+
+// SetLang sets the "lang" field.
+func (cuo *ChunkUpdateOne) SetLang(s string) *ChunkUpdateOne {
+	cuo.mutation.SetLang(s)
+	return cuo
+}
+
+// SetNillableLang sets the "lang" field if the given value is not nil.
+func (cuo *ChunkUpdateOne) SetNillableLang(s *string) *ChunkUpdateOne {
+	if s != nil {
+		cuo.SetLang(*s)
+	}
+	return cuo
+}
+
+// ClearLang clears the value of the "lang" field.
+func (cuo *ChunkUpdateOne) ClearLang() *ChunkUpdateOne {
+	cuo.mutation.ClearLang()
+	return cuo
+}
+
+// SetHeading sets the "heading" field.
+func (cuo *ChunkUpdateOne) SetHeading(s string) *ChunkUpdateOne {
+	cuo.mutation.SetHeading(s)
+	return cuo
+}
+
+// SetNillableHeading sets the "heading" field if the given value is not nil.
+func (cuo *ChunkUpdateOne) SetNillableHeading(s *string) *ChunkUpdateOne {
+	if s != nil {
+		cuo.SetHeading(*s)
+	}
+	return cuo
+}
+
+// ClearHeading clears the value of the "heading" field.
+func (cuo *ChunkUpdateOne) ClearHeading() *ChunkUpdateOne {
+	cuo.mutation.ClearHeading()
+	return cuo
+}
+
+// SetStartLine sets the "start_line" field.
+func (cuo *ChunkUpdateOne) SetStartLine(i int) *ChunkUpdateOne {
+	cuo.mutation.ResetStartLine()
+	cuo.mutation.SetStartLine(i)
+	return cuo
+}
+
+// SetNillableStartLine sets the "start_line" field if the given value is not nil.
+func (cuo *ChunkUpdateOne) SetNillableStartLine(i *int) *ChunkUpdateOne {
+	if i != nil {
+		cuo.SetStartLine(*i)
+	}
+	return cuo
+}
+
+// AddStartLine adds i to the "start_line" field.
+func (cuo *ChunkUpdateOne) AddStartLine(i int) *ChunkUpdateOne {
+	cuo.mutation.AddStartLine(i)
+	return cuo
+}
+
+// ClearStartLine clears the value of the "start_line" field.
+func (cuo *ChunkUpdateOne) ClearStartLine() *ChunkUpdateOne {
+	cuo.mutation.ClearStartLine()
+	return cuo
+}
+
+// SetEndLine sets the "end_line" field.
+func (cuo *ChunkUpdateOne) SetEndLine(i int) *ChunkUpdateOne {
+	cuo.mutation.ResetEndLine()
+	cuo.mutation.SetEndLine(i)
+	return cuo
+}
+
+// SetNillableEndLine sets the "end_line" field if the given value is not nil.
+func (cuo *ChunkUpdateOne) SetNillableEndLine(i *int) *ChunkUpdateOne {
+	if i != nil {
+		cuo.SetEndLine(*i)
+	}
+	return cuo
+}
+
+// AddEndLine adds i to the "end_line" field.
+func (cuo *ChunkUpdateOne) AddEndLine(i int) *ChunkUpdateOne {
+	cuo.mutation.AddEndLine(i)
+	return cuo
+}
+
+// ClearEndLine clears the value of the "end_line" field.
+func (cuo *ChunkUpdateOne) ClearEndLine() *ChunkUpdateOne {
+	cuo.mutation.ClearEndLine()
+	return cuo
+}
+
+// SetMetadata sets the "metadata" field.
+func (cuo *ChunkUpdateOne) SetMetadata(m map[string]string) *ChunkUpdateOne {
+	cuo.mutation.SetMetadata(m)
+	return cuo
+}
+
+// ClearMetadata clears the value of the "metadata" field.
+func (cuo *ChunkUpdateOne) ClearMetadata() *ChunkUpdateOne {
+	cuo.mutation.ClearMetadata()
+	return cuo
+}
+
 // SetEmbeddingID sets the "embedding" edge to the Embedding entity by ID.
 func (cuo *ChunkUpdateOne) SetEmbeddingID(id int) *ChunkUpdateOne {
 	cuo.mutation.SetEmbeddingID(id)
@@ -323,6 +672,12 @@ func (cuo *ChunkUpdateOne) ExecX(ctx context.Context) {
 	}
 }
 
+// Modify adds a statement modifier for attaching custom logic to the UPDATE statement.
+func (cuo *ChunkUpdateOne) Modify(modifiers ...func(u *sql.UpdateBuilder)) *ChunkUpdateOne {
+	cuo.modifiers = append(cuo.modifiers, modifiers...)
+	return cuo
+}
+
 func (cuo *ChunkUpdateOne) sqlSave(ctx context.Context) (_node *Chunk, err error) {
 	_spec := sqlgraph.NewUpdateSpec(chunk.Table, chunk.Columns, sqlgraph.NewFieldSpec(chunk.FieldID, field.TypeInt))
 	id, ok := cuo.mutation.ID()
@@ -361,6 +716,54 @@ func (cuo *ChunkUpdateOne) sqlSave(ctx context.Context) (_node *Chunk, err error
 	if value, ok := cuo.mutation.Data(); ok {
 		_spec.SetField(chunk.FieldData, field.TypeString, value)
 	}
+	if value, ok := cuo.mutation.Tsv(); ok {
+		_spec.SetField(chunk.FieldTsv, field.TypeString, value)
+	}
+	if cuo.mutation.TsvCleared() {
+		_spec.ClearField(chunk.FieldTsv, field.TypeString)
+	}
+	if value, ok := cuo.mutation.Title(); ok {
+		_spec.SetField(chunk.FieldTitle, field.TypeString, value)
+	}
+	if cuo.mutation.TitleCleared() {
+		_spec.ClearField(chunk.FieldTitle, field.TypeString)
+	}
+	if value, ok := cuo.mutation.Lang(); ok {
+		_spec.SetField(chunk.FieldLang, field.TypeString, value)
+	}
+	if cuo.mutation.LangCleared() {
+		_spec.ClearField(chunk.FieldLang, field.TypeString)
+	}
+	if value, ok := cuo.mutation.Heading(); ok {
+		_spec.SetField(chunk.FieldHeading, field.TypeString, value)
+	}
+	if cuo.mutation.HeadingCleared() {
+		_spec.ClearField(chunk.FieldHeading, field.TypeString)
+	}
+	if value, ok := cuo.mutation.StartLine(); ok {
+		_spec.SetField(chunk.FieldStartLine, field.TypeInt, value)
+	}
+	if value, ok := cuo.mutation.AddedStartLine(); ok {
+		_spec.AddField(chunk.FieldStartLine, field.TypeInt, value)
+	}
+	if cuo.mutation.StartLineCleared() {
+		_spec.ClearField(chunk.FieldStartLine, field.TypeInt)
+	}
+	if value, ok := cuo.mutation.EndLine(); ok {
+		_spec.SetField(chunk.FieldEndLine, field.TypeInt, value)
+	}
+	if value, ok := cuo.mutation.AddedEndLine(); ok {
+		_spec.AddField(chunk.FieldEndLine, field.TypeInt, value)
+	}
+	if cuo.mutation.EndLineCleared() {
+		_spec.ClearField(chunk.FieldEndLine, field.TypeInt)
+	}
+	if value, ok := cuo.mutation.Metadata(); ok {
+		_spec.SetField(chunk.FieldMetadata, field.TypeJSON, value)
+	}
+	if cuo.mutation.MetadataCleared() {
+		_spec.ClearField(chunk.FieldMetadata, field.TypeJSON)
+	}
 	if cuo.mutation.EmbeddingCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2O,
@@ -390,6 +793,7 @@ func (cuo *ChunkUpdateOne) sqlSave(ctx context.Context) (_node *Chunk, err error
 		}
 		_spec.Edges.Add = append(_spec.Edges.Add, edge)
 	}
+	_spec.AddModifiers(cuo.modifiers...)
 	_node = &Chunk{config: cuo.config}
 	_spec.Assign = _node.assignValues
 	_spec.ScanValues = _node.scanValues
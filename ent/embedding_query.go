@@ -25,6 +25,7 @@ type EmbeddingQuery struct {
 	predicates []predicate.Embedding
 	withChunk  *ChunkQuery
 	withFKs    bool
+	modifiers  []func(*sql.Selector)
 	// intermediate query (i.e. traversal path).
 	sql  *sql.Selector
 	path func(context.Context) (*sql.Selector, error)
@@ -277,8 +278,9 @@ func (eq *EmbeddingQuery) Clone() *EmbeddingQuery {
 		predicates: append([]predicate.Embedding{}, eq.predicates...),
 		withChunk:  eq.withChunk.Clone(),
 		// clone intermediate query.
-		sql:  eq.sql.Clone(),
-		path: eq.path,
+		sql:       eq.sql.Clone(),
+		path:      eq.path,
+		modifiers: append([]func(*sql.Selector){}, eq.modifiers...),
 	}
 }
 
@@ -391,6 +393,9 @@ func (eq *EmbeddingQuery) sqlAll(ctx context.Context, hooks ...queryHook) ([]*Em
 		node.Edges.loadedTypes = loadedTypes
 		return node.assignValues(columns, values)
 	}
+	if len(eq.modifiers) > 0 {
+		_spec.Modifiers = eq.modifiers
+	}
 	for i := range hooks {
 		hooks[i](ctx, _spec)
 	}
@@ -444,6 +449,9 @@ func (eq *EmbeddingQuery) loadChunk(ctx context.Context, query *ChunkQuery, node
 
 func (eq *EmbeddingQuery) sqlCount(ctx context.Context) (int, error) {
 	_spec := eq.querySpec()
+	if len(eq.modifiers) > 0 {
+		_spec.Modifiers = eq.modifiers
+	}
 	_spec.Node.Columns = eq.ctx.Fields
 	if len(eq.ctx.Fields) > 0 {
 		_spec.Unique = eq.ctx.Unique != nil && *eq.ctx.Unique
@@ -506,6 +514,9 @@ func (eq *EmbeddingQuery) sqlQuery(ctx context.Context) *sql.Selector {
 	if eq.ctx.Unique != nil && *eq.ctx.Unique {
 		selector.Distinct()
 	}
+	for _, m := range eq.modifiers {
+		m(selector)
+	}
 	for _, p := range eq.predicates {
 		p(selector)
 	}
@@ -523,6 +534,12 @@ func (eq *EmbeddingQuery) sqlQuery(ctx context.Context) *sql.Selector {
 	return selector
 }
 
+// Modify adds a query modifier for attaching custom logic to queries.
+func (eq *EmbeddingQuery) Modify(modifiers ...func(s *sql.Selector)) *EmbeddingSelect {
+	eq.modifiers = append(eq.modifiers, modifiers...)
+	return eq.Select()
+}
+
 // EmbeddingGroupBy is the group-by builder for Embedding entities.
 type EmbeddingGroupBy struct {
 	selector
@@ -612,3 +629,9 @@ func (es *EmbeddingSelect) sqlScan(ctx context.Context, root *EmbeddingQuery, v
 	defer rows.Close()
 	return sql.ScanSlice(rows, v)
 }
+
+// Modify adds a query modifier for attaching custom logic to queries.
+func (es *EmbeddingSelect) Modify(modifiers ...func(s *sql.Selector)) *EmbeddingSelect {
+	es.modifiers = append(es.modifiers, modifiers...)
+	return es
+}
@@ -19,8 +19,9 @@ import (
 // EmbeddingUpdate is the builder for updating Embedding entities.
 type EmbeddingUpdate struct {
 	config
-	hooks    []Hook
-	mutation *EmbeddingMutation
+	hooks     []Hook
+	mutation  *EmbeddingMutation
+	modifiers []func(*sql.UpdateBuilder)
 }
 
 // Where appends a list predicates to the EmbeddingUpdate builder.
@@ -100,6 +101,12 @@ func (eu *EmbeddingUpdate) check() error {
 	return nil
 }
 
+// Modify adds a statement modifier for attaching custom logic to the UPDATE statement.
+func (eu *EmbeddingUpdate) Modify(modifiers ...func(u *sql.UpdateBuilder)) *EmbeddingUpdate {
+	eu.modifiers = append(eu.modifiers, modifiers...)
+	return eu
+}
+
 func (eu *EmbeddingUpdate) sqlSave(ctx context.Context) (n int, err error) {
 	if err := eu.check(); err != nil {
 		return n, err
@@ -144,6 +151,7 @@ func (eu *EmbeddingUpdate) sqlSave(ctx context.Context) (n int, err error) {
 		}
 		_spec.Edges.Add = append(_spec.Edges.Add, edge)
 	}
+	_spec.AddModifiers(eu.modifiers...)
 	if n, err = sqlgraph.UpdateNodes(ctx, eu.driver, _spec); err != nil {
 		if _, ok := err.(*sqlgraph.NotFoundError); ok {
 			err = &NotFoundError{embedding.Label}
@@ -159,9 +167,10 @@ func (eu *EmbeddingUpdate) sqlSave(ctx context.Context) (n int, err error) {
 // EmbeddingUpdateOne is the builder for updating a single Embedding entity.
 type EmbeddingUpdateOne struct {
 	config
-	fields   []string
-	hooks    []Hook
-	mutation *EmbeddingMutation
+	fields    []string
+	hooks     []Hook
+	mutation  *EmbeddingMutation
+	modifiers []func(*sql.UpdateBuilder)
 }
 
 // SetEmbedding sets the "embedding" field.
@@ -248,6 +257,12 @@ func (euo *EmbeddingUpdateOne) check() error {
 	return nil
 }
 
+// Modify adds a statement modifier for attaching custom logic to the UPDATE statement.
+func (euo *EmbeddingUpdateOne) Modify(modifiers ...func(u *sql.UpdateBuilder)) *EmbeddingUpdateOne {
+	euo.modifiers = append(euo.modifiers, modifiers...)
+	return euo
+}
+
 func (euo *EmbeddingUpdateOne) sqlSave(ctx context.Context) (_node *Embedding, err error) {
 	if err := euo.check(); err != nil {
 		return _node, err
@@ -309,6 +324,7 @@ func (euo *EmbeddingUpdateOne) sqlSave(ctx context.Context) (_node *Embedding, e
 		}
 		_spec.Edges.Add = append(_spec.Edges.Add, edge)
 	}
+	_spec.AddModifiers(euo.modifiers...)
 	_node = &Embedding{config: euo.config}
 	_spec.Assign = _node.assignValues
 	_spec.ScanValues = _node.scanValues
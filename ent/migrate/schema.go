@@ -3,6 +3,7 @@
 package migrate
 
 import (
+	"entgo.io/ent/dialect/entsql"
 	"entgo.io/ent/dialect/sql/schema"
 	"entgo.io/ent/schema/field"
 )
@@ -14,12 +15,46 @@ var (
 		{Name: "path", Type: field.TypeString},
 		{Name: "nchunk", Type: field.TypeInt},
 		{Name: "data", Type: field.TypeString, Size: 2147483647},
+		{Name: "tsv", Type: field.TypeString, Nullable: true, SchemaType: map[string]string{"postgres": "tsvector"}},
+		{Name: "title", Type: field.TypeString, Nullable: true},
+		{Name: "lang", Type: field.TypeString, Nullable: true},
+		{Name: "heading", Type: field.TypeString, Nullable: true},
+		{Name: "start_line", Type: field.TypeInt, Nullable: true},
+		{Name: "end_line", Type: field.TypeInt, Nullable: true},
+		{Name: "metadata", Type: field.TypeJSON, Nullable: true},
 	}
 	// ChunksTable holds the schema information for the "chunks" table.
 	ChunksTable = &schema.Table{
 		Name:       "chunks",
 		Columns:    ChunksColumns,
 		PrimaryKey: []*schema.Column{ChunksColumns[0]},
+		Indexes: []*schema.Index{
+			{
+				Name:    "chunk_tsv",
+				Unique:  false,
+				Columns: []*schema.Column{ChunksColumns[4]},
+				Annotation: &entsql.IndexAnnotation{
+					Type: "GIN",
+				},
+			},
+			{
+				Name:    "chunk_path",
+				Unique:  false,
+				Columns: []*schema.Column{ChunksColumns[1]},
+				Annotation: &entsql.IndexAnnotation{
+					OpClass: "text_pattern_ops",
+				},
+			},
+			{
+				Name:    "chunk_metadata",
+				Unique:  false,
+				Columns: []*schema.Column{ChunksColumns[10]},
+				Annotation: &entsql.IndexAnnotation{
+					OpClass: "jsonb_path_ops",
+					Type:    "GIN",
+				},
+			},
+		},
 	}
 	// EmbeddingsColumns holds the columns for the "embeddings" table.
 	EmbeddingsColumns = []*schema.Column{
@@ -40,6 +75,17 @@ var (
 				OnDelete:   schema.NoAction,
 			},
 		},
+		Indexes: []*schema.Index{
+			{
+				Name:    "embedding_embedding",
+				Unique:  false,
+				Columns: []*schema.Column{EmbeddingsColumns[1]},
+				Annotation: &entsql.IndexAnnotation{
+					OpClass: "vector_l2_ops",
+					Type:    "hnsw",
+				},
+			},
+		},
 	}
 	// Tables holds all the tables in the schema.
 	Tables = []*schema.Table{
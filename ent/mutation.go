@@ -39,6 +39,15 @@ type ChunkMutation struct {
 	nchunk           *int
 	addnchunk        *int
 	data             *string
+	tsv              *string
+	title            *string
+	lang             *string
+	heading          *string
+	start_line       *int
+	addstart_line    *int
+	end_line         *int
+	addend_line      *int
+	metadata         *map[string]string
 	clearedFields    map[string]struct{}
 	embedding        *int
 	clearedembedding bool
@@ -273,6 +282,391 @@ func (m *ChunkMutation) ResetData() {
 	m.data = nil
 }
 
+// SetTsv sets the "tsv" field.
+func (m *ChunkMutation) SetTsv(s string) {
+	m.tsv = &s
+}
+
+// Tsv returns the value of the "tsv" field in the mutation.
+func (m *ChunkMutation) Tsv() (r string, exists bool) {
+	v := m.tsv
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTsv returns the old "tsv" field's value of the Chunk entity.
+// If the Chunk object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ChunkMutation) OldTsv(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTsv is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTsv requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTsv: %w", err)
+	}
+	return oldValue.Tsv, nil
+}
+
+// ClearTsv clears the value of the "tsv" field.
+func (m *ChunkMutation) ClearTsv() {
+	m.tsv = nil
+	m.clearedFields[chunk.FieldTsv] = struct{}{}
+}
+
+// TsvCleared returns if the "tsv" field was cleared in this mutation.
+func (m *ChunkMutation) TsvCleared() bool {
+	_, ok := m.clearedFields[chunk.FieldTsv]
+	return ok
+}
+
+// ResetTsv resets all changes to the "tsv" field.
+func (m *ChunkMutation) ResetTsv() {
+	m.tsv = nil
+	delete(m.clearedFields, chunk.FieldTsv)
+}
+
+// SetTitle sets the "title" field.
+func (m *ChunkMutation) SetTitle(s string) {
+	m.title = &s
+}
+
+// Title returns the value of the "title" field in the mutation.
+func (m *ChunkMutation) Title() (r string, exists bool) {
+	v := m.title
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTitle returns the old "title" field's value of the Chunk entity.
+// If the Chunk object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ChunkMutation) OldTitle(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTitle is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTitle requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTitle: %w", err)
+	}
+	return oldValue.Title, nil
+}
+
+// ClearTitle clears the value of the "title" field.
+func (m *ChunkMutation) ClearTitle() {
+	m.title = nil
+	m.clearedFields[chunk.FieldTitle] = struct{}{}
+}
+
+// TitleCleared returns if the "title" field was cleared in this mutation.
+func (m *ChunkMutation) TitleCleared() bool {
+	_, ok := m.clearedFields[chunk.FieldTitle]
+	return ok
+}
+
+// ResetTitle resets all changes to the "title" field.
+func (m *ChunkMutation) ResetTitle() {
+	m.title = nil
+	delete(m.clearedFields, chunk.FieldTitle)
+}
+
+// SetLang sets the "lang" field.
+func (m *ChunkMutation) SetLang(s string) {
+	m.lang = &s
+}
+
+// Lang returns the value of the "lang" field in the mutation.
+func (m *ChunkMutation) Lang() (r string, exists bool) {
+	v := m.lang
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldLang returns the old "lang" field's value of the Chunk entity.
+// If the Chunk object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ChunkMutation) OldLang(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldLang is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldLang requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldLang: %w", err)
+	}
+	return oldValue.Lang, nil
+}
+
+// ClearLang clears the value of the "lang" field.
+func (m *ChunkMutation) ClearLang() {
+	m.lang = nil
+	m.clearedFields[chunk.FieldLang] = struct{}{}
+}
+
+// LangCleared returns if the "lang" field was cleared in this mutation.
+func (m *ChunkMutation) LangCleared() bool {
+	_, ok := m.clearedFields[chunk.FieldLang]
+	return ok
+}
+
+// ResetLang resets all changes to the "lang" field.
+func (m *ChunkMutation) ResetLang() {
+	m.lang = nil
+	delete(m.clearedFields, chunk.FieldLang)
+}
+
+// SetHeading sets the "heading" field.
+func (m *ChunkMutation) SetHeading(s string) {
+	m.heading = &s
+}
+
+// Heading returns the value of the "heading" field in the mutation.
+func (m *ChunkMutation) Heading() (r string, exists bool) {
+	v := m.heading
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldHeading returns the old "heading" field's value of the Chunk entity.
+// If the Chunk object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ChunkMutation) OldHeading(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldHeading is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldHeading requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldHeading: %w", err)
+	}
+	return oldValue.Heading, nil
+}
+
+// ClearHeading clears the value of the "heading" field.
+func (m *ChunkMutation) ClearHeading() {
+	m.heading = nil
+	m.clearedFields[chunk.FieldHeading] = struct{}{}
+}
+
+// HeadingCleared returns if the "heading" field was cleared in this mutation.
+func (m *ChunkMutation) HeadingCleared() bool {
+	_, ok := m.clearedFields[chunk.FieldHeading]
+	return ok
+}
+
+// ResetHeading resets all changes to the "heading" field.
+func (m *ChunkMutation) ResetHeading() {
+	m.heading = nil
+	delete(m.clearedFields, chunk.FieldHeading)
+}
+
+// SetStartLine sets the "start_line" field.
+func (m *ChunkMutation) SetStartLine(i int) {
+	m.start_line = &i
+	m.addstart_line = nil
+}
+
+// StartLine returns the value of the "start_line" field in the mutation.
+func (m *ChunkMutation) StartLine() (r int, exists bool) {
+	v := m.start_line
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldStartLine returns the old "start_line" field's value of the Chunk entity.
+// If the Chunk object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ChunkMutation) OldStartLine(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldStartLine is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldStartLine requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldStartLine: %w", err)
+	}
+	return oldValue.StartLine, nil
+}
+
+// AddStartLine adds i to the "start_line" field.
+func (m *ChunkMutation) AddStartLine(i int) {
+	if m.addstart_line != nil {
+		*m.addstart_line += i
+	} else {
+		m.addstart_line = &i
+	}
+}
+
+// AddedStartLine returns the value that was added to the "start_line" field in this mutation.
+func (m *ChunkMutation) AddedStartLine() (r int, exists bool) {
+	v := m.addstart_line
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearStartLine clears the value of the "start_line" field.
+func (m *ChunkMutation) ClearStartLine() {
+	m.start_line = nil
+	m.addstart_line = nil
+	m.clearedFields[chunk.FieldStartLine] = struct{}{}
+}
+
+// StartLineCleared returns if the "start_line" field was cleared in this mutation.
+func (m *ChunkMutation) StartLineCleared() bool {
+	_, ok := m.clearedFields[chunk.FieldStartLine]
+	return ok
+}
+
+// ResetStartLine resets all changes to the "start_line" field.
+func (m *ChunkMutation) ResetStartLine() {
+	m.start_line = nil
+	m.addstart_line = nil
+	delete(m.clearedFields, chunk.FieldStartLine)
+}
+
+// SetEndLine sets the "end_line" field.
+func (m *ChunkMutation) SetEndLine(i int) {
+	m.end_line = &i
+	m.addend_line = nil
+}
+
+// EndLine returns the value of the "end_line" field in the mutation.
+func (m *ChunkMutation) EndLine() (r int, exists bool) {
+	v := m.end_line
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldEndLine returns the old "end_line" field's value of the Chunk entity.
+// If the Chunk object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ChunkMutation) OldEndLine(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldEndLine is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldEndLine requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldEndLine: %w", err)
+	}
+	return oldValue.EndLine, nil
+}
+
+// AddEndLine adds i to the "end_line" field.
+func (m *ChunkMutation) AddEndLine(i int) {
+	if m.addend_line != nil {
+		*m.addend_line += i
+	} else {
+		m.addend_line = &i
+	}
+}
+
+// AddedEndLine returns the value that was added to the "end_line" field in this mutation.
+func (m *ChunkMutation) AddedEndLine() (r int, exists bool) {
+	v := m.addend_line
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearEndLine clears the value of the "end_line" field.
+func (m *ChunkMutation) ClearEndLine() {
+	m.end_line = nil
+	m.addend_line = nil
+	m.clearedFields[chunk.FieldEndLine] = struct{}{}
+}
+
+// EndLineCleared returns if the "end_line" field was cleared in this mutation.
+func (m *ChunkMutation) EndLineCleared() bool {
+	_, ok := m.clearedFields[chunk.FieldEndLine]
+	return ok
+}
+
+// ResetEndLine resets all changes to the "end_line" field.
+func (m *ChunkMutation) ResetEndLine() {
+	m.end_line = nil
+	m.addend_line = nil
+	delete(m.clearedFields, chunk.FieldEndLine)
+}
+
+// SetMetadata sets the "metadata" field.
+func (m *ChunkMutation) SetMetadata(value map[string]string) {
+	m.metadata = &value
+}
+
+// Metadata returns the value of the "metadata" field in the mutation.
+func (m *ChunkMutation) Metadata() (r map[string]string, exists bool) {
+	v := m.metadata
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldMetadata returns the old "metadata" field's value of the Chunk entity.
+// If the Chunk object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *ChunkMutation) OldMetadata(ctx context.Context) (v map[string]string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldMetadata is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldMetadata requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldMetadata: %w", err)
+	}
+	return oldValue.Metadata, nil
+}
+
+// ClearMetadata clears the value of the "metadata" field.
+func (m *ChunkMutation) ClearMetadata() {
+	m.metadata = nil
+	m.clearedFields[chunk.FieldMetadata] = struct{}{}
+}
+
+// MetadataCleared returns if the "metadata" field was cleared in this mutation.
+func (m *ChunkMutation) MetadataCleared() bool {
+	_, ok := m.clearedFields[chunk.FieldMetadata]
+	return ok
+}
+
+// ResetMetadata resets all changes to the "metadata" field.
+func (m *ChunkMutation) ResetMetadata() {
+	m.metadata = nil
+	delete(m.clearedFields, chunk.FieldMetadata)
+}
+
 // SetEmbeddingID sets the "embedding" edge to the Embedding entity by id.
 func (m *ChunkMutation) SetEmbeddingID(id int) {
 	m.embedding = &id
@@ -346,7 +740,7 @@ func (m *ChunkMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *ChunkMutation) Fields() []string {
-	fields := make([]string, 0, 3)
+	fields := make([]string, 0, 10)
 	if m._path != nil {
 		fields = append(fields, chunk.FieldPath)
 	}
@@ -356,6 +750,27 @@ func (m *ChunkMutation) Fields() []string {
 	if m.data != nil {
 		fields = append(fields, chunk.FieldData)
 	}
+	if m.tsv != nil {
+		fields = append(fields, chunk.FieldTsv)
+	}
+	if m.title != nil {
+		fields = append(fields, chunk.FieldTitle)
+	}
+	if m.lang != nil {
+		fields = append(fields, chunk.FieldLang)
+	}
+	if m.heading != nil {
+		fields = append(fields, chunk.FieldHeading)
+	}
+	if m.start_line != nil {
+		fields = append(fields, chunk.FieldStartLine)
+	}
+	if m.end_line != nil {
+		fields = append(fields, chunk.FieldEndLine)
+	}
+	if m.metadata != nil {
+		fields = append(fields, chunk.FieldMetadata)
+	}
 	return fields
 }
 
@@ -370,6 +785,20 @@ func (m *ChunkMutation) Field(name string) (ent.Value, bool) {
 		return m.Nchunk()
 	case chunk.FieldData:
 		return m.Data()
+	case chunk.FieldTsv:
+		return m.Tsv()
+	case chunk.FieldTitle:
+		return m.Title()
+	case chunk.FieldLang:
+		return m.Lang()
+	case chunk.FieldHeading:
+		return m.Heading()
+	case chunk.FieldStartLine:
+		return m.StartLine()
+	case chunk.FieldEndLine:
+		return m.EndLine()
+	case chunk.FieldMetadata:
+		return m.Metadata()
 	}
 	return nil, false
 }
@@ -385,6 +814,20 @@ func (m *ChunkMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldNchunk(ctx)
 	case chunk.FieldData:
 		return m.OldData(ctx)
+	case chunk.FieldTsv:
+		return m.OldTsv(ctx)
+	case chunk.FieldTitle:
+		return m.OldTitle(ctx)
+	case chunk.FieldLang:
+		return m.OldLang(ctx)
+	case chunk.FieldHeading:
+		return m.OldHeading(ctx)
+	case chunk.FieldStartLine:
+		return m.OldStartLine(ctx)
+	case chunk.FieldEndLine:
+		return m.OldEndLine(ctx)
+	case chunk.FieldMetadata:
+		return m.OldMetadata(ctx)
 	}
 	return nil, fmt.Errorf("unknown Chunk field %s", name)
 }
@@ -415,6 +858,55 @@ func (m *ChunkMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetData(v)
 		return nil
+	case chunk.FieldTsv:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTsv(v)
+		return nil
+	case chunk.FieldTitle:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTitle(v)
+		return nil
+	case chunk.FieldLang:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetLang(v)
+		return nil
+	case chunk.FieldHeading:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetHeading(v)
+		return nil
+	case chunk.FieldStartLine:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetStartLine(v)
+		return nil
+	case chunk.FieldEndLine:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetEndLine(v)
+		return nil
+	case chunk.FieldMetadata:
+		v, ok := value.(map[string]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetMetadata(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Chunk field %s", name)
 }
@@ -426,6 +918,12 @@ func (m *ChunkMutation) AddedFields() []string {
 	if m.addnchunk != nil {
 		fields = append(fields, chunk.FieldNchunk)
 	}
+	if m.addstart_line != nil {
+		fields = append(fields, chunk.FieldStartLine)
+	}
+	if m.addend_line != nil {
+		fields = append(fields, chunk.FieldEndLine)
+	}
 	return fields
 }
 
@@ -436,6 +934,10 @@ func (m *ChunkMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
 	case chunk.FieldNchunk:
 		return m.AddedNchunk()
+	case chunk.FieldStartLine:
+		return m.AddedStartLine()
+	case chunk.FieldEndLine:
+		return m.AddedEndLine()
 	}
 	return nil, false
 }
@@ -452,6 +954,20 @@ func (m *ChunkMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddNchunk(v)
 		return nil
+	case chunk.FieldStartLine:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddStartLine(v)
+		return nil
+	case chunk.FieldEndLine:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddEndLine(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Chunk numeric field %s", name)
 }
@@ -459,7 +975,29 @@ func (m *ChunkMutation) AddField(name string, value ent.Value) error {
 // ClearedFields returns all nullable fields that were cleared during this
 // mutation.
 func (m *ChunkMutation) ClearedFields() []string {
-	return nil
+	var fields []string
+	if m.FieldCleared(chunk.FieldTsv) {
+		fields = append(fields, chunk.FieldTsv)
+	}
+	if m.FieldCleared(chunk.FieldTitle) {
+		fields = append(fields, chunk.FieldTitle)
+	}
+	if m.FieldCleared(chunk.FieldLang) {
+		fields = append(fields, chunk.FieldLang)
+	}
+	if m.FieldCleared(chunk.FieldHeading) {
+		fields = append(fields, chunk.FieldHeading)
+	}
+	if m.FieldCleared(chunk.FieldStartLine) {
+		fields = append(fields, chunk.FieldStartLine)
+	}
+	if m.FieldCleared(chunk.FieldEndLine) {
+		fields = append(fields, chunk.FieldEndLine)
+	}
+	if m.FieldCleared(chunk.FieldMetadata) {
+		fields = append(fields, chunk.FieldMetadata)
+	}
+	return fields
 }
 
 // FieldCleared returns a boolean indicating if a field with the given name was
@@ -472,6 +1010,29 @@ func (m *ChunkMutation) FieldCleared(name string) bool {
 // ClearField clears the value of the field with the given name. It returns an
 // error if the field is not defined in the schema.
 func (m *ChunkMutation) ClearField(name string) error {
+	switch name {
+	case chunk.FieldTsv:
+		m.ClearTsv()
+		return nil
+	case chunk.FieldTitle:
+		m.ClearTitle()
+		return nil
+	case chunk.FieldLang:
+		m.ClearLang()
+		return nil
+	case chunk.FieldHeading:
+		m.ClearHeading()
+		return nil
+	case chunk.FieldStartLine:
+		m.ClearStartLine()
+		return nil
+	case chunk.FieldEndLine:
+		m.ClearEndLine()
+		return nil
+	case chunk.FieldMetadata:
+		m.ClearMetadata()
+		return nil
+	}
 	return fmt.Errorf("unknown Chunk nullable field %s", name)
 }
 
@@ -488,6 +1049,27 @@ func (m *ChunkMutation) ResetField(name string) error {
 	case chunk.FieldData:
 		m.ResetData()
 		return nil
+	case chunk.FieldTsv:
+		m.ResetTsv()
+		return nil
+	case chunk.FieldTitle:
+		m.ResetTitle()
+		return nil
+	case chunk.FieldLang:
+		m.ResetLang()
+		return nil
+	case chunk.FieldHeading:
+		m.ResetHeading()
+		return nil
+	case chunk.FieldStartLine:
+		m.ResetStartLine()
+		return nil
+	case chunk.FieldEndLine:
+		m.ResetEndLine()
+		return nil
+	case chunk.FieldMetadata:
+		m.ResetMetadata()
+		return nil
 	}
 	return fmt.Errorf("unknown Chunk field %s", name)
 }
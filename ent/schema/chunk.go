@@ -2,8 +2,11 @@ package schema
 
 import (
 	"entgo.io/ent"
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/entsql"
 	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
 )
 
 // Chunk holds the schema definition for the Chunk entity.
@@ -17,6 +20,32 @@ func (Chunk) Fields() []ent.Field {
 		field.String("path"),
 		field.Int("nchunk"),
 		field.Text("data"),
+		// tsv holds the full-text search vector of data. It is computed in
+		// Go at load time by the same tokenizer that builds search queries.
+		field.String("tsv").
+			Optional().
+			SchemaType(map[string]string{
+				dialect.Postgres: "tsvector",
+			}),
+		// title is the title of the source document, taken from the front
+		// matter or the first heading.
+		field.String("title").
+			Optional(),
+		// lang is the detected language of data, e.g. "en" or "zh".
+		field.String("lang").
+			Optional(),
+		// heading is the Markdown heading the chunk starts under.
+		field.String("heading").
+			Optional(),
+		// start_line and end_line are the 1-based line range of the chunk
+		// in the source document.
+		field.Int("start_line").
+			Optional(),
+		field.Int("end_line").
+			Optional(),
+		// metadata holds the front matter of the source document.
+		field.JSON("metadata", map[string]string{}).
+			Optional(),
 	}
 }
 
@@ -26,3 +55,23 @@ func (Chunk) Edges() []ent.Edge {
 		edge.To("embedding", Embedding.Type).StorageKey(edge.Column("chunk_id")).Unique(),
 	}
 }
+
+func (Chunk) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tsv").
+			Annotations(
+				entsql.IndexType("GIN"),
+			),
+		// text_pattern_ops lets the index serve path prefix filters (LIKE 'x%').
+		index.Fields("path").
+			Annotations(
+				entsql.OpClass("text_pattern_ops"),
+			),
+		// jsonb_path_ops serves the containment (@>) queries of metadata filters.
+		index.Fields("metadata").
+			Annotations(
+				entsql.IndexType("GIN"),
+				entsql.OpClass("jsonb_path_ops"),
+			),
+	}
+}
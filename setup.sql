@@ -6,8 +6,21 @@ CREATE TABLE "public"."chunks" (
    "path" character varying NOT NULL,
    "nchunk" bigint NOT NULL,
    "data" text NOT NULL,
+   "tsv" tsvector NULL,
+   "title" character varying NULL,
+   "lang" character varying NULL,
+   "heading" character varying NULL,
+   "start_line" bigint NULL,
+   "end_line" bigint NULL,
+   "metadata" jsonb NULL,
    PRIMARY KEY ("id")
 );
+-- Create index "chunk_path" to table: "chunks"
+CREATE INDEX "chunk_path" ON "public"."chunks" ("path" text_pattern_ops);
+-- Create index "chunk_metadata" to table: "chunks"
+CREATE INDEX "chunk_metadata" ON "public"."chunks" USING gin ("metadata" jsonb_path_ops);
+-- Create index "chunk_tsv" to table: "chunks"
+CREATE INDEX "chunk_tsv" ON "public"."chunks" USING gin ("tsv");
 -- Create "embeddings" table
 CREATE TABLE "public"."embeddings" (
    "id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY,